@@ -1,16 +1,18 @@
 package aliyundrive
 
 import (
+	"context"
 	"crypto/tls"
-	"fmt"
-	nested "github.com/antonfisher/nested-logrus-formatter"
 	"github.com/jakeslee/aliyundrive/http"
 	"github.com/jakeslee/aliyundrive/models"
 	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/time/rate"
 	gohttp "net/http"
+	"net/url"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,36 +20,73 @@ const (
 	DefaultRootFileId = "root"
 )
 
-func init() {
-	logrus.SetFormatter(&nested.Formatter{
-		HideKeys: true,
-	})
-}
-
 type AliyunDrive struct {
 	Credentials map[string]*Credential
 
 	c                 *cron.Cron
+	logger            Logger
 	client            *http.Client
 	rawClient         *gohttp.Client
 	cache             *bigCache
 	uploadRateLimiter *rate.Limiter
-	uploadLimitEnable bool
+	// downloadRateLimiter 所有下载共享的限速
+	downloadRateLimiter *rate.Limiter
+	// credentialDownloadRate 每个 Credential 单独的下载限速，0 为不限速
+	credentialDownloadRate int
+	downloadLimitersMu     sync.Mutex
+	downloadLimiters       map[string]*rate.Limiter // 按 UserId 区分，UserId 相同的 Credential 共用
+	rateSchedulesMu        sync.Mutex
+	rateSchedules          map[cron.EntryID]*RateLimitSchedule
+	endpoint               string
+	authEndpoint           string
+	uploadEndpoint         string
+	downloadEndpoint       string
 }
 
 type Options struct {
-	AutoRefresh     bool
-	UploadRate      int
-	RefreshDuration string // 刷新周期，默认 @every 1h30m，支持 cron
-	Credential      []*Credential
+	AutoRefresh  bool
+	UploadRate   int
+	DownloadRate int // 所有下载共享的限速，单位 bytes/s，0 为不限速
+	// CredentialDownloadRate 每个 Credential 单独的下载限速，UserId 相同的 Credential 共用，单位 bytes/s，0 为不限速，与 DownloadRate 同时生效
+	CredentialDownloadRate int
+	// RateLimitSchedules 按时间段调整上传、下载限速，与自动刷新 Token 共用 cron 调度，创建时立即应用当前所处时间段的限速
+	RateLimitSchedules []*RateLimitSchedule
+	RefreshDuration    string // 刷新周期，默认 @every 1h30m，支持 cron
+	Credential         []*Credential
+	Transport          gohttp.RoundTripper // 自定义 HTTP Transport，可用于代理或测试，为空使用默认配置
+	// Interceptors 请求拦截器，作用于 API 请求以及分片上传、下载请求
+	Interceptors []*http.Interceptor
+	// Logger 日志输出，为空时使用 logrus 的 StandardLogger，可使用 NewLogrusLogger、NewSlogLogger 或 NewNopLogger 创建
+	Logger Logger
+
+	QPS                   float64       // API 请求每秒最大数量，0 为不限制
+	QPSBurst              int           // API 请求允许的突发数量，默认 1
+	MaxConcurrentRequests int           // API 请求最大并发数，0 为不限制
+	MaxRetries            int           // API 请求失败的最大重试次数，默认 3，小于 0 不重试
+	RetryWaitMin          time.Duration // 首次重试等待时间，之后按指数退避并加入随机抖动，服务端返回 Retry-After 时以其为准，不超过 RetryWaitMax
+	RetryWaitMax          time.Duration // 最长重试等待时间，包括 Retry-After，默认 10s
+
+	Endpoint         string // API 地址，默认 models.AliyunDriveEndpoint
+	AuthEndpoint     string // 认证 API 地址，默认 models.AliyunDriveAuthEndpoint
+	UploadEndpoint   string // 分片上传地址，设置后替换上传 URL 的协议、主机，原路径拼接在其后
+	DownloadEndpoint string // 下载地址，设置后替换下载 URL 的协议、主机，原路径拼接在其后
 }
 
 func NewClient(options *Options) *AliyunDrive {
 	drive := &AliyunDrive{
-		Credentials:       make(map[string]*Credential),
-		client:            http.NewClient(),
-		c:                 cron.New(),
-		uploadRateLimiter: rate.NewLimiter(rate.Limit(options.UploadRate), options.UploadRate),
+		Credentials:            make(map[string]*Credential),
+		client:                 http.NewClient(),
+		c:                      cron.New(),
+		logger:                 options.Logger,
+		uploadRateLimiter:      newRateLimiter(options.UploadRate),
+		downloadRateLimiter:    newRateLimiter(options.DownloadRate),
+		endpoint:               strings.TrimSuffix(options.Endpoint, "/"),
+		authEndpoint:           strings.TrimSuffix(options.AuthEndpoint, "/"),
+		uploadEndpoint:         strings.TrimSuffix(options.UploadEndpoint, "/"),
+		downloadEndpoint:       strings.TrimSuffix(options.DownloadEndpoint, "/"),
+		credentialDownloadRate: options.CredentialDownloadRate,
+		downloadLimiters:       make(map[string]*rate.Limiter),
+		rateSchedules:          make(map[cron.EntryID]*RateLimitSchedule),
 		rawClient: &gohttp.Client{
 			Transport: &gohttp.Transport{
 				TLSClientConfig: &tls.Config{
@@ -57,10 +96,37 @@ func NewClient(options *Options) *AliyunDrive {
 		},
 	}
 
+	drive.client.
+		SetRateLimit(options.QPS, options.QPSBurst).
+		SetMaxConcurrency(options.MaxConcurrentRequests).
+		SetRetry(&http.RetryOptions{
+			MaxRetries: options.MaxRetries,
+			WaitMin:    options.RetryWaitMin,
+			WaitMax:    options.RetryWaitMax,
+		})
+
+	if drive.logger == nil {
+		drive.logger = NewLogrusLogger(logrus.StandardLogger())
+	}
+
+	if options.Transport != nil {
+		drive.client.SetTransport(options.Transport)
+		drive.rawClient.Transport = options.Transport
+	}
+
+	if len(options.Interceptors) > 0 {
+		drive.client.WrapTransport(func(transport gohttp.RoundTripper) gohttp.RoundTripper {
+			return http.NewInterceptTransport(transport, options.Interceptors)
+		})
+
+		drive.rawClient.Transport = http.NewInterceptTransport(drive.rawClient.Transport, options.Interceptors)
+	}
+
 	drive.cache, _ = newBigCache(&bigCacheOptions{
 		ttl:       5 * time.Minute,
 		size:      0,
 		cleanFreq: time.Minute,
+		logger:    drive.logger,
 	})
 
 	if len(options.Credential) > 0 {
@@ -83,51 +149,113 @@ func NewClient(options *Options) *AliyunDrive {
 		drive.c.Start()
 
 		if err != nil {
-			logrus.Warnf("create auto refresh token job error: %s", err)
+			drive.logger.Warn("create auto refresh token job error", "spec", spec, "error", err)
 		}
 
-		logrus.Infof("job: refresh token, schedule as %s", spec)
+		drive.logger.Info("job: refresh token scheduled", "spec", spec)
 	}
 
-	printStr := ""
-	if options.UploadRate != 0 {
-		drive.uploadLimitEnable = true
-		printStr = fmt.Sprintf(", speed limit: %d bytes/s", options.UploadRate)
+	if options.UploadRate != 0 || options.DownloadRate != 0 || options.CredentialDownloadRate != 0 {
+		drive.logger.Info("speed limit", "upload_rate", options.UploadRate, "download_rate", options.DownloadRate,
+			"credential_download_rate", options.CredentialDownloadRate)
 	}
 
-	logrus.Infof("rate limit mode: %v"+printStr, drive.uploadLimitEnable)
+	for _, schedule := range options.RateLimitSchedules {
+		if _, err := drive.AddRateLimitSchedule(schedule); err != nil {
+			drive.logger.Warn("create rate limit schedule error", "spec", schedule.Spec, "error", err)
+		}
+	}
 
 	return drive
 }
 
-func (d *AliyunDrive) send(credential *Credential, r http.Request, response http.Response) error {
-	if credential.AccessToken != "" {
+// send 使用 credential 的 AccessToken 发送请求，Token 失效时自动刷新并重试，credential 为 nil 时发送匿名请求
+func (d *AliyunDrive) send(ctx context.Context, credential *Credential, r http.Request, response http.Response) error {
+	d.rewriteEndpoint(r)
+
+	if credential != nil && credential.AccessToken != "" {
 		models.WithToken(r, credential.AccessToken)
 	}
 
-	err := d.client.Send(r, response)
+	err := d.client.SendWithContext(ctx, r, response)
 
 	// 如果是 AliyunDriveError 需要检查是否需要刷新 Token
 	if _, ok := err.(*http.AliyunDriveError); !ok && err != nil {
 		return err
 	}
 
+	if value := baseResponse(response); credential != nil && value != nil && value.Code == models.CodeAccessTokenInvalid {
+		_, err := d.RefreshTokenWithContext(ctx, credential)
+		if err != nil {
+			return err
+		}
+
+		// 使用新的 AccessToken 重试，并清除上次请求的错误信息
+		models.WithToken(r, credential.AccessToken)
+		value.Code, value.Message = "", ""
+
+		return d.client.SendWithContext(ctx, r, response)
+	}
+
+	return err
+}
+
+// baseResponse 返回 response 中嵌入的 BaseResponse，不存在时返回 nil
+func baseResponse(response http.Response) *http.BaseResponse {
+	if value, ok := response.(*http.BaseResponse); ok {
+		return value
+	}
+
 	baseValue := reflect.ValueOf(response).Elem().FieldByName("BaseResponse")
 
 	if baseValue.IsValid() {
 		if value, ok := baseValue.Addr().Interface().(*http.BaseResponse); ok {
-			if value.Code == models.CodeAccessTokenInvalid {
-				_, err := d.RefreshToken(credential)
-				if err != nil {
-					return err
-				}
-
-				return d.client.Send(r, response)
-			}
+			return value
 		}
 	}
 
-	return err
+	return nil
+}
+
+// rewriteEndpoint 使用 Options 中配置的地址替换请求默认的 API 地址
+func (d *AliyunDrive) rewriteEndpoint(r http.Request) {
+	switch r.GetEndpoint() {
+	case models.AliyunDriveEndpoint:
+		if d.endpoint != "" {
+			r.SetEndpoint(d.endpoint)
+		}
+	case models.AliyunDriveAuthEndpoint:
+		if d.authEndpoint != "" {
+			r.SetEndpoint(d.authEndpoint)
+		}
+	}
+}
+
+// rewriteURL 使用 endpoint 替换 rawUrl 的协议和主机，endpoint 为空时返回原 URL
+func rewriteURL(rawUrl, endpoint string) (string, error) {
+	if endpoint == "" {
+		return rawUrl, nil
+	}
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = target.Scheme
+	u.Host = target.Host
+	u.Path = target.Path + u.Path
+
+	if u.RawPath != "" {
+		u.RawPath = target.EscapedPath() + u.RawPath
+	}
+
+	return u.String(), nil
 }
 
 // EvictCacheWithPrefix 失效 Key 前缀为 keyPrefix 的缓存
@@ -0,0 +1,160 @@
+package aliyundrive
+
+import (
+	"fmt"
+	"github.com/jakeslee/aliyundrive/http"
+	gohttp "net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAliyunDrive_RetryThrottled(t *testing.T) {
+	drive, cred, server := newTestClientWithOptions(t, &Options{
+		RetryWaitMin: 10 * time.Millisecond,
+		RetryWaitMax: 2 * time.Second,
+	})
+
+	server.AddFile(DefaultRootFileId, "a.txt", []byte("a"))
+
+	server.Throttle(2, 0)
+
+	resp, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId})
+	if err != nil || len(resp.Items) != 1 {
+		t.Fatalf("throttled request should be retried, got %v", err)
+	}
+
+	// 服务端返回 Retry-After 时至少等待对应时间
+	drive.EvictCacheWithPrefix("")
+	server.Throttle(1, time.Second)
+
+	start := time.Now()
+
+	if _, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId}); err != nil {
+		t.Fatalf("get folder files error %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retry should honour Retry-After, took %s", elapsed)
+	}
+
+	drive.EvictCacheWithPrefix("")
+	server.Throttle(4, 0)
+
+	if _, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId}); err == nil {
+		t.Errorf("request should fail after max retries")
+	} else if e, ok := err.(*http.AliyunDriveError); !ok || e.Code != "TooManyRequests" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestAliyunDrive_RetryAfterClamped(t *testing.T) {
+	drive, cred, server := newTestClientWithOptions(t, &Options{
+		RetryWaitMin: 10 * time.Millisecond,
+		RetryWaitMax: 100 * time.Millisecond,
+	})
+
+	// Retry-After 超过 RetryWaitMax 时只等待 RetryWaitMax
+	server.Throttle(1, time.Hour)
+
+	start := time.Now()
+
+	if _, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId}); err != nil {
+		t.Fatalf("get folder files error %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Retry-After should be clamped to RetryWaitMax, took %s", elapsed)
+	}
+}
+
+func TestAliyunDrive_QPS(t *testing.T) {
+	drive, cred, server := newTestClientWithOptions(t, &Options{
+		QPS:                   20,
+		MaxConcurrentRequests: 2,
+	})
+
+	count := server.RequestCount()
+	start := time.Now()
+
+	for i := 0; i < 10; i++ {
+		drive.EvictCacheWithPrefix("")
+
+		if _, err := drive.GetFile(cred, DefaultRootFileId); err != nil {
+			t.Fatalf("get file error %v", err)
+		}
+	}
+
+	// 桶容量为 1，10 个请求至少需要 9 个间隔
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("requests should be limited by QPS, took %s", elapsed)
+	}
+
+	if server.RequestCount()-count < 10 {
+		t.Errorf("unexpected request count %d", server.RequestCount()-count)
+	}
+}
+
+func TestAliyunDrive_MaxConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	var inflight, maxInflight int
+
+	drive, cred, server := newTestClientWithOptions(t, &Options{
+		MaxConcurrentRequests: 2,
+		Interceptors: []*http.Interceptor{
+			{
+				BeforeRequest: func(request *gohttp.Request) error {
+					mu.Lock()
+					inflight++
+					if inflight > maxInflight {
+						maxInflight = inflight
+					}
+					mu.Unlock()
+
+					// 延长请求时间，让并发的请求重叠
+					time.Sleep(20 * time.Millisecond)
+
+					return nil
+				},
+				AfterResponse: func(request *gohttp.Request, response *gohttp.Response, err error, elapsed time.Duration) {
+					mu.Lock()
+					inflight--
+					mu.Unlock()
+				},
+			},
+		},
+	})
+
+	var fileIds []string
+
+	for i := 0; i < 8; i++ {
+		fileIds = append(fileIds, server.AddFile(DefaultRootFileId, fmt.Sprintf("%d.txt", i), []byte("a")).FileId)
+	}
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, len(fileIds))
+
+	for _, fileId := range fileIds {
+		wg.Add(1)
+
+		go func(fileId string) {
+			defer wg.Done()
+
+			if _, err := drive.GetFile(cred, fileId); err != nil {
+				errs <- err
+			}
+		}(fileId)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("get file error %v", err)
+	}
+
+	if maxInflight != 2 {
+		t.Errorf("expect at most 2 concurrent requests and requests to overlap, got %d", maxInflight)
+	}
+}
@@ -1,11 +1,11 @@
 package aliyundrive
 
 import (
-	"errors"
+	"context"
 	"github.com/asaskevich/EventBus"
+	"github.com/jakeslee/aliyundrive/http"
 	"github.com/jakeslee/aliyundrive/models"
 	"github.com/jinzhu/copier"
-	"github.com/sirupsen/logrus"
 	"math/rand"
 	"strconv"
 )
@@ -17,6 +17,8 @@ type Credential struct {
 	RefreshToken   string
 	RootFolder     string
 	DefaultDriveId string
+	SboxDriveId    string          // 保险箱 DriveId，未开通时为空
+	Drives         []*models.Drive // 用户的所有 Drive，AddCredential 和 ListDrives 时更新
 	eventbus       EventBus.Bus
 }
 
@@ -44,34 +46,57 @@ func (c *Credential) RegisterChangeEvent(fn func(credential *Credential)) *Crede
 }
 
 func (d *AliyunDrive) RefreshAllToken() {
+	d.RefreshAllTokenWithContext(context.Background())
+}
+
+// RefreshAllTokenWithContext 同 RefreshAllToken，通过 ctx 控制取消和超时
+func (d *AliyunDrive) RefreshAllTokenWithContext(ctx context.Context) {
 	for name, credential := range d.Credentials {
-		_, err := d.RefreshToken(credential)
+		_, err := d.RefreshTokenWithContext(ctx, credential)
 		if err != nil {
-			logrus.Errorf("error occurred refreshing token %s, error: %s", name, err)
+			d.logger.Error("refresh token error", "user_id", name, "error", err)
 		}
 	}
 }
 
 // RefreshToken 刷新 RefreshToken，更新 AccessToken 和 Credential 里的相关信息
 func (d *AliyunDrive) RefreshToken(credential *Credential) (*models.RefreshTokenResponse, error) {
+	return d.RefreshTokenWithContext(context.Background(), credential)
+}
+
+// RefreshTokenWithContext 同 RefreshToken，通过 ctx 控制取消和超时
+func (d *AliyunDrive) RefreshTokenWithContext(ctx context.Context, credential *Credential) (*models.RefreshTokenResponse, error) {
 	refreshTokenRequest := models.NewRefreshTokenRequest()
 	refreshTokenRequest.RefreshToken = credential.RefreshToken
 	var token models.RefreshTokenResponse
 
-	err := d.send(credential, refreshTokenRequest, &token)
+	err := d.send(ctx, credential, refreshTokenRequest, &token)
 
 	if token.Code != "" {
-		logrus.Errorf("refresh token error: %s", token.Message)
-		return &token, errors.New(token.Message)
+		d.logger.Error("refresh token error", "user_id", credential.UserId, "code", token.Code, "message", token.Message)
+
+		if err == nil {
+			err = http.NewAliyunDriveError(token.Code, token.Message)
+		}
+
+		return &token, err
+	}
+
+	// 请求未成功时 token 为空，不能覆盖 credential，否则订阅者会保存空的 RefreshToken
+	if err != nil {
+		return &token, err
 	}
 
 	credential.RefreshToken = token.RefreshToken
 	credential.AccessToken = token.AccessToken
 	credential.Name = token.NickName
 	credential.DefaultDriveId = token.DefaultDriveId
+	credential.SboxDriveId = token.DefaultSboxDriveId
 
 	credential.eventbus.Publish(eventTokenChange, credential)
 
+	d.logger.Debug("token refreshed", "user_id", token.UserId, "name", token.NickName)
+
 	_, ok := d.Credentials[token.UserId]
 
 	if !ok || credential.UserId != token.UserId {
@@ -81,17 +106,29 @@ func (d *AliyunDrive) RefreshToken(credential *Credential) (*models.RefreshToken
 		credential.UserId = token.UserId
 	}
 
-	return &token, err
+	return &token, nil
 }
 
 // AddCredential 增加新的 Credential，同时刷新 RefreshToken
 func (d *AliyunDrive) AddCredential(credential *Credential) (*Credential, error) {
+	return d.AddCredentialWithContext(context.Background(), credential)
+}
+
+// AddCredentialWithContext 同 AddCredential，通过 ctx 控制取消和超时
+func (d *AliyunDrive) AddCredentialWithContext(ctx context.Context, credential *Credential) (*Credential, error) {
 	credential.UserId = strconv.Itoa(rand.Intn(100000))
 
 	d.Credentials[credential.UserId] = credential
-	_, err := d.RefreshToken(credential)
+	_, err := d.RefreshTokenWithContext(ctx, credential)
+	if err != nil {
+		return credential, err
+	}
 
-	return credential, err
+	if _, err := d.ListDrivesWithContext(ctx, credential); err != nil {
+		d.logger.Warn("list drives error", "user_id", credential.UserId, "name", credential.Name, "error", err)
+	}
+
+	return credential, nil
 }
 
 // GetCredentialFromUserId 通过 UserId 取 Credential
@@ -101,11 +138,16 @@ func (d *AliyunDrive) GetCredentialFromUserId(userId string) *Credential {
 
 // GetUserInfo get user information
 func (d *AliyunDrive) GetUserInfo(credential *Credential) (*models.UserInfo, error) {
+	return d.GetUserInfoWithContext(context.Background(), credential)
+}
+
+// GetUserInfoWithContext 同 GetUserInfo，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetUserInfoWithContext(ctx context.Context, credential *Credential) (*models.UserInfo, error) {
 	userInfoRequest := models.NewUserInfoRequest()
 
 	var user models.UserInfo
 
-	err := d.send(credential, userInfoRequest, &user)
+	err := d.send(ctx, credential, userInfoRequest, &user)
 
 	return &user, err
 }
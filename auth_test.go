@@ -1,15 +1,60 @@
 package aliyundrive
 
 import (
+	"context"
+	"errors"
+	"github.com/jakeslee/aliyundrive/drivetest"
 	"os"
 	"testing"
+	"time"
 )
 
 var (
 	refreshToken = os.Getenv("refreshToken")
 )
 
+// requireRefreshToken 访问真实阿里云盘的测试需要设置 refreshToken 环境变量
+func requireRefreshToken(t *testing.T) {
+	if refreshToken == "" {
+		t.Skip("refreshToken is not set, skip testing against aliyundrive")
+	}
+}
+
+// setInterval 在测试期间将包级别的等待间隔 *interval 修改为 value，测试结束后恢复
+func setInterval(t *testing.T, interval *time.Duration, value time.Duration) {
+	old := *interval
+	*interval = value
+
+	t.Cleanup(func() { *interval = old })
+}
+
+// newTestClient 创建连接到 drivetest 模拟服务的客户端
+func newTestClient(t *testing.T) (*AliyunDrive, *Credential, *drivetest.Server) {
+	return newTestClientWithOptions(t, &Options{})
+}
+
+// newTestClientWithOptions 同 newTestClient，options 的 Transport 会被替换为模拟服务
+func newTestClientWithOptions(t *testing.T, options *Options) (*AliyunDrive, *Credential, *drivetest.Server) {
+	server := drivetest.NewServer()
+	t.Cleanup(server.Close)
+
+	options.Transport = server.Transport()
+
+	drive := NewClient(options)
+
+	cred, err := drive.AddCredential(NewCredential(&Credential{
+		RefreshToken: server.RefreshToken,
+	}))
+	if err != nil {
+		t.Fatalf("add credential error %v", err)
+	}
+
+	return drive, cred, server
+}
+
 func TestRefreshToken(t *testing.T) {
+	requireRefreshToken(t)
+
 	drive := NewClient(&Options{
 		AutoRefresh: true,
 	})
@@ -32,6 +77,8 @@ func TestRefreshToken(t *testing.T) {
 }
 
 func TestGetUserInfo(t *testing.T) {
+	requireRefreshToken(t)
+
 	cred := NewCredential(&Credential{
 		RefreshToken: refreshToken,
 	})
@@ -55,3 +102,67 @@ func TestGetUserInfo(t *testing.T) {
 
 	t.Logf("get user info %+v", info)
 }
+
+func TestAliyunDrive_RefreshExpiredToken(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	if cred.UserId != drivetest.DefaultUserId || cred.DefaultDriveId != server.DriveId {
+		t.Fatalf("unexpected credential %+v", cred)
+	}
+
+	server.ExpireAccessToken()
+
+	info, err := drive.GetUserInfo(cred)
+	if err != nil {
+		t.Fatalf("get user info error %v", err)
+	}
+
+	if info.UserId != drivetest.DefaultUserId {
+		t.Errorf("unexpected user info %+v", info)
+	}
+
+	if cred.AccessToken != server.AccessToken() {
+		t.Errorf("access token not refreshed, got %s", cred.AccessToken)
+	}
+}
+
+func TestAliyunDrive_AddCredentialInvalidToken(t *testing.T) {
+	server := drivetest.NewServer()
+	defer server.Close()
+
+	drive := NewClient(&Options{
+		Transport: server.Transport(),
+	})
+
+	_, err := drive.AddCredential(NewCredential(&Credential{
+		RefreshToken: "invalid",
+	}))
+	if err == nil {
+		t.Fatal("expect error with invalid refresh token")
+	}
+}
+
+func TestAliyunDrive_RefreshTokenCanceled(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	changed := false
+	cred.RegisterChangeEvent(func(credential *Credential) {
+		changed = true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := drive.RefreshTokenWithContext(ctx, cred)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect context canceled, got %v", err)
+	}
+
+	if changed {
+		t.Error("token change event published on failed refresh")
+	}
+
+	if cred.RefreshToken == "" || cred.AccessToken != server.AccessToken() || cred.DefaultDriveId != server.DriveId {
+		t.Errorf("credential overwritten on failed refresh: %+v", cred)
+	}
+}
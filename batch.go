@@ -0,0 +1,393 @@
+package aliyundrive
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jakeslee/aliyundrive/http"
+	"github.com/jakeslee/aliyundrive/models"
+)
+
+// BatchResult 批量操作中单个文件的执行结果
+type BatchResult struct {
+	FileId  string
+	Status  int                      // 子请求的 HTTP 状态码
+	Code    string                   // 失败时的错误码
+	Message string                   // 失败时的错误信息
+	File    *models.File             // 重命名成功时返回的文件信息
+	Copy    *models.CopyFileResponse // 复制成功时返回的新文件信息
+}
+
+// Success 子请求是否执行成功
+func (r *BatchResult) Success() bool {
+	return r.Status >= 200 && r.Status < 300 && r.Code == ""
+}
+
+// Err 子请求失败时返回 AliyunDriveError，成功返回 nil
+func (r *BatchResult) Err() error {
+	if r.Success() {
+		return nil
+	}
+
+	return &http.AliyunDriveError{
+		Code:       r.Code,
+		Message:    r.Message,
+		StatusCode: r.Status,
+		Retryable:  http.IsRetryable(r.Status, r.Code),
+	}
+}
+
+type BatchRenameItem struct {
+	FileId string
+	Name   string
+}
+
+// BatchMove 批量移动文件到 toParentFileId 目录，结果顺序与 fileIds 一致
+func (d *AliyunDrive) BatchMove(credential *Credential, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	return d.BatchMoveWithContext(context.Background(), credential, fileIds, toParentFileId)
+}
+
+// BatchMoveWithContext 同 BatchMove，通过 ctx 控制取消和超时
+func (d *AliyunDrive) BatchMoveWithContext(ctx context.Context, credential *Credential, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	return d.BatchMoveInDriveWithContext(ctx, credential, "", fileIds, toParentFileId)
+}
+
+// BatchMoveInDrive 同 BatchMove，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) BatchMoveInDrive(credential *Credential, driveId string, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	return d.BatchMoveInDriveWithContext(context.Background(), credential, driveId, fileIds, toParentFileId)
+}
+
+// BatchMoveInDriveWithContext 同 BatchMoveInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) BatchMoveInDriveWithContext(ctx context.Context, credential *Credential, driveId string, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	var requests []*models.MoveFileRequest
+
+	for _, fileId := range fileIds {
+		request := models.NewMoveFileRequest()
+
+		request.DriveId = d.driveId(credential, driveId)
+		request.ToDriveId = d.driveId(credential, driveId)
+		request.FileId = fileId
+		request.ToParentFileId = toParentFileId
+
+		requests = append(requests, request)
+	}
+
+	return d.batch(ctx, credential, &batchOptions{
+		driveId:      driveId,
+		fileIds:      fileIds,
+		evictFiles:   true,
+		evictFolders: []string{toParentFileId},
+		newRequest: func(from, to int) *models.BatchRequest {
+			return models.NewBatchMoveRequest(requests[from:to])
+		},
+	})
+}
+
+// BatchRemove 批量删除文件到回收站，结果顺序与 fileIds 一致
+func (d *AliyunDrive) BatchRemove(credential *Credential, fileIds []string) ([]*BatchResult, error) {
+	return d.BatchRemoveWithContext(context.Background(), credential, fileIds)
+}
+
+// BatchRemoveWithContext 同 BatchRemove，通过 ctx 控制取消和超时
+func (d *AliyunDrive) BatchRemoveWithContext(ctx context.Context, credential *Credential, fileIds []string) ([]*BatchResult, error) {
+	return d.BatchRemoveInDriveWithContext(ctx, credential, "", fileIds)
+}
+
+// BatchRemoveInDrive 同 BatchRemove，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) BatchRemoveInDrive(credential *Credential, driveId string, fileIds []string) ([]*BatchResult, error) {
+	return d.BatchRemoveInDriveWithContext(context.Background(), credential, driveId, fileIds)
+}
+
+// BatchRemoveInDriveWithContext 同 BatchRemoveInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) BatchRemoveInDriveWithContext(ctx context.Context, credential *Credential, driveId string, fileIds []string) ([]*BatchResult, error) {
+	var requests []*models.RemoveFileRequest
+
+	for _, fileId := range fileIds {
+		request := models.NewRemoveFileRequest()
+
+		request.DriveId = d.driveId(credential, driveId)
+		request.FileId = fileId
+
+		requests = append(requests, request)
+	}
+
+	return d.batch(ctx, credential, &batchOptions{
+		driveId:    driveId,
+		fileIds:    fileIds,
+		evictFiles: true,
+		newRequest: func(from, to int) *models.BatchRequest {
+			return models.NewBatchRemoveRequest(requests[from:to])
+		},
+	})
+}
+
+// BatchCopy 批量复制文件到 toParentFileId 目录，同名文件自动重命名，结果顺序与 fileIds 一致
+func (d *AliyunDrive) BatchCopy(credential *Credential, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	return d.BatchCopyWithContext(context.Background(), credential, fileIds, toParentFileId)
+}
+
+// BatchCopyWithContext 同 BatchCopy，通过 ctx 控制取消和超时
+func (d *AliyunDrive) BatchCopyWithContext(ctx context.Context, credential *Credential, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	return d.BatchCopyInDriveWithContext(ctx, credential, "", fileIds, toParentFileId)
+}
+
+// BatchCopyInDrive 同 BatchCopy，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) BatchCopyInDrive(credential *Credential, driveId string, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	return d.BatchCopyInDriveWithContext(context.Background(), credential, driveId, fileIds, toParentFileId)
+}
+
+// BatchCopyInDriveWithContext 同 BatchCopyInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) BatchCopyInDriveWithContext(ctx context.Context, credential *Credential, driveId string, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	var requests []*models.CopyFileRequest
+
+	for _, fileId := range fileIds {
+		request := models.NewCopyFileRequest()
+
+		request.DriveId = d.driveId(credential, driveId)
+		request.ToDriveId = d.driveId(credential, driveId)
+		request.FileId = fileId
+		request.ToParentFileId = toParentFileId
+
+		requests = append(requests, request)
+	}
+
+	return d.batch(ctx, credential, &batchOptions{
+		driveId:      driveId,
+		fileIds:      fileIds,
+		evictFolders: []string{toParentFileId},
+		newRequest: func(from, to int) *models.BatchRequest {
+			return models.NewBatchCopyRequest(requests[from:to])
+		},
+		parseBody: func(result *BatchResult, body []byte) error {
+			result.Copy = &models.CopyFileResponse{}
+
+			return json.Unmarshal(body, result.Copy)
+		},
+	})
+}
+
+// BatchRename 批量重命名文件，结果顺序与 items 一致
+func (d *AliyunDrive) BatchRename(credential *Credential, items []*BatchRenameItem) ([]*BatchResult, error) {
+	return d.BatchRenameWithContext(context.Background(), credential, items)
+}
+
+// BatchRenameWithContext 同 BatchRename，通过 ctx 控制取消和超时
+func (d *AliyunDrive) BatchRenameWithContext(ctx context.Context, credential *Credential, items []*BatchRenameItem) ([]*BatchResult, error) {
+	return d.BatchRenameInDriveWithContext(ctx, credential, "", items)
+}
+
+// BatchRenameInDrive 同 BatchRename，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) BatchRenameInDrive(credential *Credential, driveId string, items []*BatchRenameItem) ([]*BatchResult, error) {
+	return d.BatchRenameInDriveWithContext(context.Background(), credential, driveId, items)
+}
+
+// BatchRenameInDriveWithContext 同 BatchRenameInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) BatchRenameInDriveWithContext(ctx context.Context, credential *Credential, driveId string, items []*BatchRenameItem) ([]*BatchResult, error) {
+	var requests []*models.RenameFileRequest
+	var fileIds []string
+
+	for _, item := range items {
+		request := models.NewRenameFileRequest()
+
+		request.DriveId = d.driveId(credential, driveId)
+		request.FileId = item.FileId
+		request.Name = item.Name
+
+		requests = append(requests, request)
+		fileIds = append(fileIds, item.FileId)
+	}
+
+	return d.batch(ctx, credential, &batchOptions{
+		driveId:    driveId,
+		fileIds:    fileIds,
+		evictFiles: true,
+		newRequest: func(from, to int) *models.BatchRequest {
+			return models.NewBatchRenameRequest(requests[from:to])
+		},
+		parseBody: func(result *BatchResult, body []byte) error {
+			result.File = &models.File{}
+
+			return json.Unmarshal(body, result.File)
+		},
+	})
+}
+
+type batchOptions struct {
+	driveId      string
+	fileIds      []string
+	evictFiles   bool     // 成功后失效文件自身及原父目录的缓存
+	evictFolders []string // 成功后需要失效缓存的目录
+	newRequest   func(from, to int) *models.BatchRequest
+	parseBody    func(result *BatchResult, body []byte) error
+	// send 发送每批请求，为空时使用 credential 发送
+	send func(ctx context.Context, r http.Request, response http.Response) error
+}
+
+// batch 按 models.BatchRequestsLimit 分批发送请求，并失效受影响目录的缓存
+func (d *AliyunDrive) batch(ctx context.Context, credential *Credential, options *batchOptions) ([]*BatchResult, error) {
+	parents := make(map[string]string)
+	results := make([]*BatchResult, 0, len(options.fileIds))
+
+	send := options.send
+	if send == nil {
+		send = func(ctx context.Context, r http.Request, response http.Response) error {
+			return d.send(ctx, credential, r, response)
+		}
+	}
+
+	defer func() {
+		succeed := false
+
+		for _, result := range results {
+			if !result.Success() {
+				continue
+			}
+
+			succeed = true
+
+			if options.evictFiles {
+				d.EvictCacheWithPrefix(result.FileId)
+
+				if parent, ok := parents[result.FileId]; ok {
+					d.EvictCacheWithPrefix(parent)
+				}
+			}
+		}
+
+		if succeed {
+			for _, folder := range options.evictFolders {
+				d.EvictCacheWithPrefix(folder)
+			}
+
+			if options.evictFiles {
+				d.evictPathCache()
+			}
+		}
+	}()
+
+	for from := 0; from < len(options.fileIds); from += models.BatchRequestsLimit {
+		to := from + models.BatchRequestsLimit
+		if to > len(options.fileIds) {
+			to = len(options.fileIds)
+		}
+
+		// 执行前获取父目录，移动后父目录信息将不再准确
+		if options.evictFiles {
+			err := d.fillParentFileIds(ctx, credential, options.driveId, options.fileIds[from:to], parents)
+			if err != nil {
+				return results, err
+			}
+		}
+
+		var resp models.BatchResponse
+
+		err := send(ctx, options.newRequest(from, to), &resp)
+		if err != nil {
+			return results, err
+		}
+
+		results = append(results, parseBatchResults(options.fileIds[from:to], resp.Responses, options.parseBody)...)
+	}
+
+	return results, nil
+}
+
+// parseBatchResults 按请求顺序整理子请求结果
+func parseBatchResults(fileIds []string, responses []*models.BatchResponseItem,
+	parseBody func(result *BatchResult, body []byte) error) []*BatchResult {
+	byId := make(map[string][]*models.BatchResponseItem)
+
+	for _, response := range responses {
+		byId[response.Id] = append(byId[response.Id], response)
+	}
+
+	results := make([]*BatchResult, 0, len(fileIds))
+
+	for _, fileId := range fileIds {
+		result := &BatchResult{
+			FileId: fileId,
+		}
+
+		results = append(results, result)
+
+		items := byId[fileId]
+		if len(items) == 0 {
+			result.Code = "ClientError.MissingBatchResponse"
+			result.Message = "no response for file " + fileId
+			continue
+		}
+
+		item := items[0]
+		byId[fileId] = items[1:]
+
+		result.Status = item.Status
+
+		if len(item.Body) == 0 {
+			continue
+		}
+
+		var base http.BaseResponse
+
+		if err := json.Unmarshal(item.Body, &base); err != nil {
+			result.Code = "ClientError.ParseJsonError"
+			result.Message = err.Error()
+			continue
+		}
+
+		result.Code = base.Code
+		result.Message = base.Message
+
+		if result.Success() && parseBody != nil {
+			if err := parseBody(result, item.Body); err != nil {
+				result.Code = "ClientError.ParseJsonError"
+				result.Message = err.Error()
+			}
+		}
+	}
+
+	return results
+}
+
+// fillParentFileIds 获取文件的父目录 ID，优先使用缓存，未缓存的文件合并为一次批量请求获取
+func (d *AliyunDrive) fillParentFileIds(ctx context.Context, credential *Credential, driveId string, fileIds []string,
+	parents map[string]string) error {
+	var requests []*models.FileRequest
+
+	driveId = d.driveId(credential, driveId)
+
+	for _, fileId := range fileIds {
+		if v, err := d.cache.Get(fileCacheKey(driveId, fileId)); err == nil {
+			if resp, ok := v.(*models.FileResponse); ok && resp.File != nil {
+				parents[fileId] = resp.ParentFileId
+				continue
+			}
+		}
+
+		request := models.NewFileRequest()
+
+		request.DriveId = driveId
+		request.FileId = fileId
+
+		requests = append(requests, request)
+	}
+
+	if len(requests) == 0 {
+		return nil
+	}
+
+	var resp models.BatchResponse
+
+	err := d.send(ctx, credential, models.NewBatchGetRequest(requests), &resp)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range resp.Responses {
+		var file models.File
+
+		if err := json.Unmarshal(item.Body, &file); err == nil && file.ParentFileId != "" {
+			parents[item.Id] = file.ParentFileId
+		}
+	}
+
+	return nil
+}
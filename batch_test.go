@@ -0,0 +1,102 @@
+package aliyundrive
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAliyunDrive_BatchMove(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	target := server.AddFolder(DefaultRootFileId, "target")
+
+	var fileIds []string
+
+	for i := 0; i < 150; i++ {
+		file := server.AddFile(DefaultRootFileId, fmt.Sprintf("file-%d.txt", i), []byte{byte(i)})
+		fileIds = append(fileIds, file.FileId)
+	}
+
+	// 缓存根目录列表，移动后应失效
+	if _, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId}); err != nil {
+		t.Fatalf("get folder files error %v", err)
+	}
+
+	results, err := drive.BatchMove(cred, append(fileIds, "not-exist"), target.FileId)
+	if err != nil {
+		t.Fatalf("batch move error %v", err)
+	}
+
+	if len(results) != len(fileIds)+1 {
+		t.Fatalf("expect %d results, got %d", len(fileIds)+1, len(results))
+	}
+
+	for i, result := range results[:len(fileIds)] {
+		if result.FileId != fileIds[i] || result.Err() != nil {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+
+	if last := results[len(fileIds)]; last.Success() || last.Code != "NotFound.File" {
+		t.Errorf("expect not found result, got %+v", last)
+	}
+
+	if children := server.Children(target.FileId); len(children) != len(fileIds) {
+		t.Errorf("expect %d files in target, got %d", len(fileIds), len(children))
+	}
+
+	files, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId})
+	if err != nil || len(files.Items) != 1 {
+		t.Errorf("root folder cache should be evicted, got %+v, %v", files, err)
+	}
+}
+
+func TestAliyunDrive_BatchCopyRenameRemove(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	target := server.AddFolder(DefaultRootFileId, "target")
+	a := server.AddFile(DefaultRootFileId, "a.txt", []byte("a"))
+	b := server.AddFile(DefaultRootFileId, "b.txt", []byte("b"))
+
+	copies, err := drive.BatchCopy(cred, []string{a.FileId, b.FileId}, target.FileId)
+	if err != nil {
+		t.Fatalf("batch copy error %v", err)
+	}
+
+	for _, result := range copies {
+		if result.Err() != nil || result.Copy == nil || result.Copy.FileId == result.FileId {
+			t.Fatalf("unexpected copy result %+v", result)
+		}
+	}
+
+	renames, err := drive.BatchRename(cred, []*BatchRenameItem{
+		{FileId: a.FileId, Name: "c.txt"},
+		{FileId: b.FileId, Name: "c.txt"},
+	})
+	if err != nil {
+		t.Fatalf("batch rename error %v", err)
+	}
+
+	if renames[0].Err() != nil || renames[0].File.Name != "c.txt" {
+		t.Errorf("unexpected rename result %+v", renames[0])
+	}
+
+	if renames[1].Success() || renames[1].Code != "AlreadyExist.File" {
+		t.Errorf("expect rename conflict, got %+v", renames[1])
+	}
+
+	removes, err := drive.BatchRemove(cred, []string{copies[0].Copy.FileId, copies[1].Copy.FileId})
+	if err != nil {
+		t.Fatalf("batch remove error %v", err)
+	}
+
+	for _, result := range removes {
+		if result.Err() != nil {
+			t.Errorf("unexpected remove result %+v", result)
+		}
+	}
+
+	if children := server.Children(target.FileId); len(children) != 0 {
+		t.Errorf("expect target empty, got %d files", len(children))
+	}
+}
@@ -4,19 +4,20 @@ import (
 	"bytes"
 	"encoding/gob"
 	"github.com/allegro/bigcache/v3"
-	"github.com/sirupsen/logrus"
 	"strings"
 	"time"
 )
 
 type bigCache struct {
-	cache *bigcache.BigCache
+	cache  *bigcache.BigCache
+	logger Logger
 }
 
 type bigCacheOptions struct {
 	size      int
 	ttl       time.Duration
 	cleanFreq time.Duration
+	logger    Logger
 }
 
 func newBigCache(options *bigCacheOptions) (*bigCache, error) {
@@ -39,7 +40,8 @@ func newBigCache(options *bigCacheOptions) (*bigCache, error) {
 	}
 
 	return &bigCache{
-		cache: cache,
+		cache:  cache,
+		logger: options.logger,
 	}, nil
 }
 
@@ -60,7 +62,7 @@ func (b *bigCache) Get(key string) (interface{}, error) {
 func (b *bigCache) Set(key string, value interface{}) error {
 	valueBytes, err := serialize(value)
 	if err != nil {
-		logrus.Errorf("serialize error %s", err)
+		b.logger.Error("serialize cache value error", "key", key, "error", err)
 		return err
 	}
 
@@ -72,6 +74,10 @@ func (b *bigCache) Set(key string, value interface{}) error {
 	return nil
 }
 
+func (b *bigCache) Delete(key string) {
+	_ = b.cache.Delete(key)
+}
+
 func (b *bigCache) RemoveWithPrefix(prefix string) int {
 	iterator := b.cache.Iterator()
 	count := 0
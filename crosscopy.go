@@ -0,0 +1,142 @@
+package aliyundrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jakeslee/aliyundrive/models"
+	"io"
+	http2 "net/http"
+)
+
+// CrossAccountCopy 将 src 账号中的文件复制到 dst 账号的 targetParentFileId 目录，同名文件自动重命名。
+// 先使用源文件的 ContentHash 在 dst 秒传，proof code 所需的 8 字节通过分段下载从 src 读取；
+// 秒传失败时下载完整文件并上传到秒传时创建的文件中，不支持复制目录
+func (d *AliyunDrive) CrossAccountCopy(src, dst *Credential, fileId, targetParentFileId string) (file *models.File, rapid bool, err error) {
+	return d.CrossAccountCopyWithContext(context.Background(), src, dst, fileId, targetParentFileId)
+}
+
+// CrossAccountCopyWithContext 同 CrossAccountCopy，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CrossAccountCopyWithContext(ctx context.Context, src, dst *Credential, fileId, targetParentFileId string) (file *models.File, rapid bool, err error) {
+	return d.CrossAccountCopyInDriveWithContext(ctx, src, "", dst, "", fileId, targetParentFileId)
+}
+
+// CrossAccountCopyInDrive 同 CrossAccountCopy，从 src 的 srcDriveId 复制到 dst 的 dstDriveId，
+// DriveId 为空时使用对应 Credential 的 DefaultDriveId
+func (d *AliyunDrive) CrossAccountCopyInDrive(src *Credential, srcDriveId string, dst *Credential, dstDriveId, fileId,
+	targetParentFileId string) (file *models.File, rapid bool, err error) {
+	return d.CrossAccountCopyInDriveWithContext(context.Background(), src, srcDriveId, dst, dstDriveId, fileId, targetParentFileId)
+}
+
+// CrossAccountCopyInDriveWithContext 同 CrossAccountCopyInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CrossAccountCopyInDriveWithContext(ctx context.Context, src *Credential, srcDriveId string, dst *Credential,
+	dstDriveId, fileId, targetParentFileId string) (file *models.File, rapid bool, err error) {
+	source, err := d.GetFileInDriveWithContext(ctx, src, srcDriveId, fileId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if source.Type == models.FileTypeFolder {
+		return nil, false, errors.New("cross account copy does not support folder")
+	}
+
+	var pending *models.CreateWithFoldersWithProofResponse
+
+	if source.ContentHash != "" {
+		file, pending, err = d.createRapid(ctx, dst, &rapidCreateOptions{
+			driveId:      dstDriveId,
+			parentFileId: targetParentFileId,
+			name:         source.Name,
+			size:         source.Size,
+			sha1:         source.ContentHash,
+			proof: &rangeReaderAt{
+				ctx:        ctx,
+				drive:      d,
+				credential: src,
+				driveId:    srcDriveId,
+				fileId:     fileId,
+			},
+		})
+		if err != nil {
+			return nil, false, err
+		}
+
+		if file != nil {
+			return file, true, nil
+		}
+	}
+
+	file, err = d.streamCopy(ctx, src, srcDriveId, dst, dstDriveId, source.File, targetParentFileId, pending)
+
+	// 上传失败时删除秒传创建的待上传文件
+	if err != nil && pending != nil {
+		if _, err := d.DeletePermanentlyInDriveWithContext(ctx, dst, dstDriveId, pending.FileId); err != nil {
+			d.logger.Warn("delete pending upload error", "file_id", pending.FileId, "error", err)
+		}
+	}
+
+	return file, false, err
+}
+
+// streamCopy 下载 source 并上传到 dst，pending 不为空时上传到秒传时已创建的文件，否则新建文件上传
+func (d *AliyunDrive) streamCopy(ctx context.Context, src *Credential, srcDriveId string, dst *Credential, dstDriveId string,
+	source *models.File, targetParentFileId string, pending *models.CreateWithFoldersWithProofResponse) (*models.File, error) {
+	response, err := d.DownloadInDriveWithContext(ctx, src, srcDriveId, source.FileId, "")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http2.StatusOK {
+		return nil, fmt.Errorf("download file %s error, status: %d", source.FileId, response.StatusCode)
+	}
+
+	if pending == nil {
+		return d.UploadFileWithContext(ctx, dst, &UploadFileOptions{
+			DriveId:      dstDriveId,
+			Name:         source.Name,
+			Size:         source.Size,
+			ParentFileId: targetParentFileId,
+			Reader:       response.Body,
+		})
+	}
+
+	return d.uploadParts(ctx, dst, &uploadPartsOptions{
+		reader:       response.Body,
+		driveId:      dstDriveId,
+		fileId:       pending.FileId,
+		uploadId:     pending.UploadId,
+		partInfoList: pending.PartInfoList,
+		progressDone: func(info *ProgressInfo) {
+			d.EvictCacheWithPrefix(targetParentFileId)
+		},
+	})
+}
+
+// rangeReaderAt 通过分段下载实现 io.ReaderAt，每次 ReadAt 发起一次请求，适合读取少量数据
+type rangeReaderAt struct {
+	ctx        context.Context
+	drive      *AliyunDrive
+	credential *Credential
+	driveId    string
+	fileId     string
+}
+
+func (r *rangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	response, err := r.drive.DownloadInDriveWithContext(r.ctx, r.credential, r.driveId, r.fileId,
+		fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1))
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http2.StatusPartialContent {
+		return 0, fmt.Errorf("download range of file %s error, status: %d", r.fileId, response.StatusCode)
+	}
+
+	return io.ReadFull(response.Body, p)
+}
@@ -0,0 +1,77 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestAliyunDrive_CrossAccountCopy(t *testing.T) {
+	drive, src, server := newTestClient(t)
+
+	user := server.AddUser("other")
+
+	dst, err := drive.AddCredential(NewCredential(&Credential{
+		RefreshToken: user.RefreshToken,
+	}))
+	if err != nil {
+		t.Fatalf("add credential error %v", err)
+	}
+
+	if dst.DefaultDriveId != user.DriveId {
+		t.Fatalf("unexpected dst drive %s", dst.DefaultDriveId)
+	}
+
+	content := bytes.Repeat([]byte("cross account"), 1000)
+	source := server.AddFile(DefaultRootFileId, "source.bin", content)
+
+	file, rapid, err := drive.CrossAccountCopy(src, dst, source.FileId, DefaultRootFileId)
+	if err != nil {
+		t.Fatalf("cross account copy error %v", err)
+	}
+
+	if !rapid || file.DriveId != user.DriveId || file.Name != "source.bin" {
+		t.Errorf("expect rapid copy to dst drive, got rapid: %v, file: %+v", rapid, file)
+	}
+
+	// 秒传失败时下载后上传
+	server.DisableRapidUpload()
+
+	file, rapid, err = drive.CrossAccountCopyWithContext(context.Background(), src, dst, source.FileId, DefaultRootFileId)
+	if err != nil {
+		t.Fatalf("cross account copy error %v", err)
+	}
+
+	if rapid || file.Name != "source(1).bin" {
+		t.Errorf("expect streaming copy, got rapid: %v, file: %+v", rapid, file)
+	}
+
+	if uploaded := server.DriveChildren(user.DriveId, DefaultRootFileId); len(uploaded) != 2 {
+		t.Errorf("unexpected dst files %+v", uploaded)
+	}
+
+	if copied, _ := server.Content(source.FileId); !bytes.Equal(copied, content) {
+		t.Errorf("source content should not change")
+	}
+
+	// 秒传未匹配时上传到已创建的文件，不留下待上传的文件
+	if pending := server.PendingUploads(); pending != 0 {
+		t.Errorf("expect no pending uploads, got %d", pending)
+	}
+
+	if uploaded, _ := server.DriveContent(user.DriveId, file.FileId); !bytes.Equal(uploaded, content) {
+		t.Errorf("unexpected streamed content, size %d", len(uploaded))
+	}
+
+	// 源文件和目标分别在两个账号的不同 Drive 中
+	secret := server.AddDriveFile(src.SboxDriveId, DefaultRootFileId, "secret.bin", content)
+
+	file, _, err = drive.CrossAccountCopyInDrive(src, src.SboxDriveId, dst, "", secret.FileId, DefaultRootFileId)
+	if err != nil {
+		t.Fatalf("cross account copy from sbox error %v", err)
+	}
+
+	if file.DriveId != user.DriveId || file.Name != "secret.bin" {
+		t.Errorf("expect copy from sbox to dst drive, got %+v", file)
+	}
+}
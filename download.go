@@ -0,0 +1,359 @@
+package aliyundrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jakeslee/aliyundrive/models"
+	"io"
+	http2 "net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DownloadConcurrencyDefault 默认并发下载数
+	DownloadConcurrencyDefault = 4
+	// DownloadChunkSizeDefault 默认分段大小 10MB
+	DownloadChunkSizeDefault = 1024 * 1024 * 10
+	// downloadChunkRetry 单个分段下载失败后的重试次数
+	downloadChunkRetry = 3
+	// downloadPartSuffix 下载中的数据文件后缀，下载完成并校验后重命名为目标文件
+	downloadPartSuffix = ".part"
+	// downloadStateSuffix 下载进度文件后缀，与数据文件放在同一目录
+	downloadStateSuffix = ".part.json"
+)
+
+// downloadChunkRetryInterval 分段下载失败后重试的间隔
+var downloadChunkRetryInterval = time.Second
+
+type DownloadToFileOptions struct {
+	DriveId          string           // 文件所在的 Drive，为空时使用 credential.DefaultDriveId
+	Concurrency      int              // 并发下载数，默认 DownloadConcurrencyDefault
+	ChunkSize        int64            // 分段大小，默认 DownloadChunkSizeDefault
+	ProgressCallback ProgressCallback // 每次写入数据后回调，返回 false 时中止下载并保留进度
+}
+
+// downloadState 下载进度，中断后再次下载同一文件时跳过已完成的分段
+type downloadState struct {
+	FileId      string `json:"file_id"`
+	Size        int64  `json:"size"`
+	ContentHash string `json:"content_hash"`
+	ChunkSize   int64  `json:"chunk_size"`
+	Done        []bool `json:"done"`
+}
+
+// DownloadToFile 并发分段下载文件到 path。下载过程中数据写入 path.part，进度保存在 path.part.json，
+// 中断后再次调用会跳过已完成的分段；完成后使用文件的 ContentHash 和 Crc64Hash 校验，校验通过才重命名为 path
+func (d *AliyunDrive) DownloadToFile(credential *Credential, fileId, path string, options *DownloadToFileOptions) (*models.File, error) {
+	return d.DownloadToFileWithContext(context.Background(), credential, fileId, path, options)
+}
+
+// DownloadToFileWithContext 同 DownloadToFile，通过 ctx 控制取消和超时
+func (d *AliyunDrive) DownloadToFileWithContext(ctx context.Context, credential *Credential, fileId, path string,
+	options *DownloadToFileOptions) (*models.File, error) {
+	if options == nil {
+		options = &DownloadToFileOptions{}
+	}
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DownloadConcurrencyDefault
+	}
+
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DownloadChunkSizeDefault
+	}
+
+	fileResp, err := d.GetFileInDriveWithContext(ctx, credential, options.DriveId, fileId)
+	if err != nil {
+		return nil, err
+	}
+
+	file := fileResp.File
+
+	if file.Type == models.FileTypeFolder {
+		return nil, fmt.Errorf("file %s is a folder", fileId)
+	}
+
+	state := loadDownloadState(path + downloadStateSuffix)
+
+	if state == nil || state.FileId != fileId || state.Size != file.Size ||
+		state.ContentHash != file.ContentHash || state.ChunkSize != chunkSize {
+		state = &downloadState{
+			FileId:      fileId,
+			Size:        file.Size,
+			ContentHash: file.ContentHash,
+			ChunkSize:   chunkSize,
+			Done:        make([]bool, (file.Size+chunkSize-1)/chunkSize),
+		}
+
+		// 进度不匹配时重新下载
+		_ = os.Remove(path + downloadPartSuffix)
+	}
+
+	part, err := os.OpenFile(path+downloadPartSuffix, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.downloadChunks(ctx, credential, &downloadChunksOptions{
+		driveId:          options.DriveId,
+		file:             file,
+		part:             part,
+		state:            state,
+		statePath:        path + downloadStateSuffix,
+		concurrency:      concurrency,
+		progressCallback: options.ProgressCallback,
+	})
+	if err == nil {
+		err = part.Truncate(file.Size)
+	}
+
+	if err == nil {
+		err = verifyDownload(file, part)
+	}
+
+	if closeErr := part.Close(); err == nil {
+		err = closeErr
+	}
+
+	// 校验失败时进度已不可信，删除后下次重新下载
+	if errors.Is(err, ErrChecksumMismatch) {
+		_ = os.Remove(path + downloadPartSuffix)
+		_ = os.Remove(path + downloadStateSuffix)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(path+downloadPartSuffix, path); err != nil {
+		return nil, err
+	}
+
+	_ = os.Remove(path + downloadStateSuffix)
+
+	return file, nil
+}
+
+type downloadChunksOptions struct {
+	driveId          string
+	file             *models.File
+	part             *os.File
+	state            *downloadState
+	statePath        string
+	concurrency      int
+	progressCallback ProgressCallback
+}
+
+// downloadChunks 并发下载未完成的分段，任一分段失败时取消其余分段
+func (d *AliyunDrive) downloadChunks(ctx context.Context, credential *Credential, options *downloadChunksOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := make(chan int)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+
+	progress := options.progressCallback
+	if progress != nil {
+		progress = func(readCount int64) bool {
+			mu.Lock()
+			defer mu.Unlock()
+
+			return options.progressCallback(readCount)
+		}
+	}
+
+	for i := 0; i < options.concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for index := range chunks {
+				err := d.downloadChunk(ctx, credential, options, index, progress)
+
+				mu.Lock()
+
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+
+					cancel()
+				} else {
+					options.state.Done[index] = true
+
+					// 先将分段数据写入磁盘再保存状态，否则崩溃后已完成的分段可能是空数据
+					if err := options.part.Sync(); err != nil {
+						d.logger.Warn("sync download part error", "path", options.part.Name(), "error", err)
+					} else if err := saveDownloadState(options.statePath, options.state); err != nil {
+						d.logger.Warn("save download state error", "path", options.statePath, "error", err)
+					}
+				}
+
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for index, done := range options.state.Done {
+		if done {
+			continue
+		}
+
+		select {
+		case chunks <- index:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(chunks)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
+
+// downloadChunk 下载一个分段并写入对应位置，失败时重试，重试时已回调过的数据不会重复回调进度
+func (d *AliyunDrive) downloadChunk(ctx context.Context, credential *Credential, options *downloadChunksOptions,
+	index int, progress ProgressCallback) error {
+	start := int64(index) * options.state.ChunkSize
+	end := Min(start+options.state.ChunkSize, options.file.Size) - 1
+
+	chunkProgress := &retryProgress{callback: progress}
+
+	for retry := 0; ; retry++ {
+		err := d.downloadRange(ctx, credential, options.driveId, options.file.FileId, options.part, start, end,
+			chunkProgress.attempt())
+		if err == nil || retry >= downloadChunkRetry || ctx.Err() != nil || errors.Is(err, errUserStop) {
+			return err
+		}
+
+		d.logger.Warn("download range error", "file_id", options.file.FileId, "start", start, "end", end,
+			"error", err, "retry", retry+1)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(downloadChunkRetryInterval):
+		}
+	}
+}
+
+// downloadRange 下载 [start, end] 范围的数据并写入 writer 的对应位置
+func (d *AliyunDrive) downloadRange(ctx context.Context, credential *Credential, driveId, fileId string, writer io.WriterAt,
+	start, end int64, progress ProgressCallback) error {
+	response, err := d.DownloadInDriveWithContext(ctx, credential, driveId, fileId, fmt.Sprintf("bytes=%d-%d", start, end))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http2.StatusPartialContent {
+		return fmt.Errorf("download file %s range %d-%d error, status: %d", fileId, start, end, response.StatusCode)
+	}
+
+	reader := &progressReader{
+		Reader:   io.LimitReader(response.Body, end-start+1),
+		Callback: progress,
+	}
+
+	buf := make([]byte, 64*1024)
+	offset := start
+
+	for {
+		n, err := reader.Read(buf)
+
+		if n > 0 {
+			if _, err := writer.WriteAt(buf[:n], offset); err != nil {
+				return err
+			}
+
+			offset += int64(n)
+		}
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if offset != end+1 {
+		return fmt.Errorf("download file %s range %d-%d error, got %d bytes", fileId, start, end, offset-start)
+	}
+
+	return nil
+}
+
+// verifyDownload 校验下载的文件内容，服务端未返回 HASH 时跳过
+func verifyDownload(file *models.File, part io.ReadSeeker) error {
+	if file.ContentHash == "" && file.Crc64Hash == "" {
+		return nil
+	}
+
+	hasher := NewHasher(&HasherOptions{
+		Crc64: file.Crc64Hash != "",
+	})
+
+	if _, err := part.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(hasher, part); err != nil {
+		return err
+	}
+
+	if file.ContentHash != "" && (file.ContentHashName == "" || strings.EqualFold(file.ContentHashName, "sha1")) &&
+		!strings.EqualFold(file.ContentHash, hasher.Sha1()) {
+		return fmt.Errorf("%w: file %s sha1 %s, local %s", ErrChecksumMismatch, file.FileId, file.ContentHash, hasher.Sha1())
+	}
+
+	if file.Crc64Hash != "" && file.Crc64Hash != hasher.Crc64() {
+		return fmt.Errorf("%w: file %s crc64 %s, local %s", ErrChecksumMismatch, file.FileId, file.Crc64Hash, hasher.Crc64())
+	}
+
+	return nil
+}
+
+func loadDownloadState(path string) *downloadState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var state downloadState
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+
+	return &state
+}
+
+func saveDownloadState(path string, state *downloadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
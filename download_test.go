@@ -0,0 +1,195 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"errors"
+	"github.com/jakeslee/aliyundrive/http"
+	"io"
+	gohttp "net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAliyunDrive_DownloadToFile(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	setInterval(t, &downloadChunkRetryInterval, 10*time.Millisecond)
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	file := server.AddFile(DefaultRootFileId, "download.bin", content)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "download.bin")
+
+	downloaded, err := drive.DownloadToFile(cred, file.FileId, path, &DownloadToFileOptions{
+		Concurrency: 3,
+		ChunkSize:   1000,
+	})
+	if err != nil {
+		t.Fatalf("download to file error %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); downloaded.FileId != file.FileId || !bytes.Equal(data, content) {
+		t.Errorf("unexpected downloaded content, size %d", len(data))
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("part and state files should be removed, got %d entries", len(entries))
+	}
+
+	empty := server.AddFile(DefaultRootFileId, "empty.bin", nil)
+
+	if _, err := drive.DownloadToFile(cred, empty.FileId, filepath.Join(dir, "empty.bin"), nil); err != nil {
+		t.Errorf("download empty file error %v", err)
+	}
+}
+
+func TestAliyunDrive_DownloadToFileResume(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	content := bytes.Repeat([]byte("resume"), 1000)
+	file := server.AddFile(DefaultRootFileId, "resume.bin", content)
+
+	path := filepath.Join(t.TempDir(), "resume.bin")
+
+	var read int64
+
+	options := &DownloadToFileOptions{
+		Concurrency: 1,
+		ChunkSize:   1000,
+		ProgressCallback: func(readCount int64) bool {
+			read += readCount
+
+			// 下载完两个分段后中断
+			return read <= 2500
+		},
+	}
+
+	if _, err := drive.DownloadToFile(cred, file.FileId, path, options); !errors.Is(err, errUserStop) {
+		t.Fatalf("download should be stopped, got %v", err)
+	}
+
+	if _, err := os.Stat(path + downloadStateSuffix); err != nil {
+		t.Fatalf("download state should be saved, %v", err)
+	}
+
+	read = 0
+	options.ProgressCallback = func(readCount int64) bool {
+		read += readCount
+		return true
+	}
+
+	if _, err := drive.DownloadToFile(cred, file.FileId, path, options); err != nil {
+		t.Fatalf("resume download error %v", err)
+	}
+
+	if read != int64(len(content))-2000 {
+		t.Errorf("only remaining chunks should be downloaded, got %d bytes", read)
+	}
+
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, content) {
+		t.Errorf("unexpected resumed content, size %d", len(data))
+	}
+}
+
+func TestAliyunDrive_DownloadRateLimit(t *testing.T) {
+	drive, cred, server := newTestClientWithOptions(t, &Options{
+		DownloadRate:           40000,
+		CredentialDownloadRate: 20000,
+	})
+
+	content := bytes.Repeat([]byte("x"), 30000)
+	file := server.AddFile(DefaultRootFileId, "limited.bin", content)
+
+	start := time.Now()
+
+	response, err := drive.Download(cred, file.FileId, "")
+	if err != nil {
+		t.Fatalf("download error %v", err)
+	}
+
+	data, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+
+	if err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("read limited body error %v, got %d bytes", err, len(data))
+	}
+
+	// 单个 Credential 限速 20000 bytes/s，桶内初始 20000 字节，剩余 10000 字节约需 0.5s
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("download should be limited, took %s", elapsed)
+	}
+}
+
+// brokenBody 读取 remain 字节后返回错误，模拟下载中断
+type brokenBody struct {
+	io.ReadCloser
+	remain int
+}
+
+func (b *brokenBody) Read(p []byte) (int, error) {
+	if b.remain <= 0 {
+		return 0, errors.New("connection reset")
+	}
+
+	if len(p) > b.remain {
+		p = p[:b.remain]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.remain -= n
+
+	return n, err
+}
+
+func TestAliyunDrive_DownloadToFileRetryProgress(t *testing.T) {
+	var once sync.Once
+
+	drive, cred, server := newTestClientWithOptions(t, &Options{
+		Interceptors: []*http.Interceptor{
+			{
+				// 第一个分段下载一半后中断
+				AfterResponse: func(request *gohttp.Request, response *gohttp.Response, err error, elapsed time.Duration) {
+					if err == nil && strings.HasPrefix(request.URL.Path, "/download/") {
+						once.Do(func() {
+							response.Body = &brokenBody{ReadCloser: response.Body, remain: 500}
+						})
+					}
+				},
+			},
+		},
+	})
+
+	setInterval(t, &downloadChunkRetryInterval, 10*time.Millisecond)
+
+	content := bytes.Repeat([]byte("retry"), 1000)
+	file := server.AddFile(DefaultRootFileId, "retry.bin", content)
+
+	path := filepath.Join(t.TempDir(), "retry.bin")
+
+	var read int64
+
+	_, err := drive.DownloadToFile(cred, file.FileId, path, &DownloadToFileOptions{
+		Concurrency: 1,
+		ChunkSize:   1000,
+		ProgressCallback: func(readCount int64) bool {
+			read += readCount
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatalf("download to file error %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, content) {
+		t.Errorf("unexpected downloaded content, size %d", len(data))
+	}
+
+	if read != int64(len(content)) {
+		t.Errorf("retried chunk should not be reported twice, progress %d, size %d", read, len(content))
+	}
+}
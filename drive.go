@@ -0,0 +1,59 @@
+package aliyundrive
+
+import (
+	"context"
+	"github.com/jakeslee/aliyundrive/models"
+)
+
+// driveId 返回本次操作的 DriveId，未指定时使用 credential.DefaultDriveId，
+// 如需操作保险箱等其它 Drive，传入 credential.SboxDriveId 或 credential.Drives 中的 DriveId
+func (d *AliyunDrive) driveId(credential *Credential, driveId string) string {
+	if driveId != "" {
+		return driveId
+	}
+
+	return credential.DefaultDriveId
+}
+
+// fileCacheKey 文件缓存 Key，不同 Drive 的根目录 FileId 均为 root，需要带上 DriveId 区分。
+// Key 以 FileId 开头，EvictCacheWithPrefix(fileId) 会失效所有 Drive 中该 FileId 的缓存
+func fileCacheKey(driveId, fileId string) string {
+	return fileId + "@" + driveId
+}
+
+// ListDrives 获取用户的所有 Drive，并更新 credential.Drives
+func (d *AliyunDrive) ListDrives(credential *Credential) ([]*models.Drive, error) {
+	return d.ListDrivesWithContext(context.Background(), credential)
+}
+
+// ListDrivesWithContext 同 ListDrives，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ListDrivesWithContext(ctx context.Context, credential *Credential) ([]*models.Drive, error) {
+	var drives []*models.Drive
+
+	marker := ""
+
+	for {
+		request := models.NewListDrivesRequest()
+
+		request.Marker = marker
+
+		var resp models.ListDrivesResponse
+
+		err := d.send(ctx, credential, request, &resp)
+		if err != nil {
+			return drives, err
+		}
+
+		drives = append(drives, resp.Items...)
+
+		if resp.NextMarker == "" {
+			break
+		}
+
+		marker = resp.NextMarker
+	}
+
+	credential.Drives = drives
+
+	return drives, nil
+}
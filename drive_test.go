@@ -0,0 +1,62 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestAliyunDrive_SboxDrive(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	if cred.SboxDriveId != server.SboxDriveId || len(cred.Drives) != 2 {
+		t.Fatalf("unexpected credential drives %s, %+v", cred.SboxDriveId, cred.Drives)
+	}
+
+	server.AddFile(DefaultRootFileId, "default.txt", []byte("default"))
+	secret := server.AddDriveFile(server.SboxDriveId, DefaultRootFileId, "secret.txt", []byte("secret"))
+
+	// 两个 Drive 的根目录 FileId 相同，缓存不能混用
+	files, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId})
+	if err != nil || len(files.Items) != 1 || files.Items[0].Name != "default.txt" {
+		t.Fatalf("unexpected default root files %+v, %v", files, err)
+	}
+
+	files, err = drive.GetFolderFiles(cred, &FolderFilesOptions{DriveId: cred.SboxDriveId, FolderFileId: DefaultRootFileId})
+	if err != nil || len(files.Items) != 1 || files.Items[0].FileId != secret.FileId {
+		t.Fatalf("unexpected sbox root files %+v, %v", files, err)
+	}
+
+	uploaded, err := drive.UploadFile(cred, &UploadFileOptions{
+		DriveId:      cred.SboxDriveId,
+		Name:         "upload.txt",
+		Size:         6,
+		ParentFileId: DefaultRootFileId,
+		Reader:       bytes.NewReader([]byte("upload")),
+	})
+	if err != nil {
+		t.Fatalf("upload to sbox error %v", err)
+	}
+
+	if uploaded.DriveId != server.SboxDriveId || len(server.DriveChildren(server.SboxDriveId, DefaultRootFileId)) != 2 {
+		t.Errorf("file should be uploaded to sbox, got %+v", uploaded)
+	}
+
+	response, err := drive.DownloadInDrive(cred, cred.SboxDriveId, secret.FileId, "")
+	if err != nil {
+		t.Fatalf("download from sbox error %v", err)
+	}
+	defer response.Body.Close()
+
+	if body, _ := io.ReadAll(response.Body); string(body) != "secret" {
+		t.Errorf("unexpected sbox content %s", body)
+	}
+
+	if _, err := drive.GetFile(cred, secret.FileId); err == nil {
+		t.Errorf("sbox file should not be found in default drive")
+	}
+
+	if file, err := drive.GetFileInDrive(cred, cred.SboxDriveId, secret.FileId); err != nil || file.Name != "secret.txt" {
+		t.Errorf("get sbox file got %+v, %v", file, err)
+	}
+}
@@ -0,0 +1,1136 @@
+package drivetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/jakeslee/aliyundrive/models"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// operation 处理一个 JSON 接口请求，返回 HTTP 状态码和响应内容，供 HTTP 路由和 /v3/batch 共用
+type operation func(s *Server, body []byte) (int, interface{})
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newError(status int, code, message string) (int, interface{}) {
+	return status, &errorBody{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func notFound(fileId string) (int, interface{}) {
+	return newError(http.StatusNotFound, "NotFound.File",
+		fmt.Sprintf("The resource file cannot be found. file not exist: %s", fileId))
+}
+
+func badRequest(message string) (int, interface{}) {
+	return newError(http.StatusBadRequest, "InvalidParameter", message)
+}
+
+// batchOperations /v3/batch 支持的子请求
+var batchOperations = map[string]operation{
+	"/file/copy":          (*Server).copy,
+	"/file/delete":        (*Server).deleteFile,
+	"/file/get":           (*Server).get,
+	"/file/move":          (*Server).move,
+	"/file/update":        (*Server).update,
+	"/recyclebin/restore": (*Server).restore,
+	"/recyclebin/trash":   (*Server).trash,
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v2/account/token", s.handle((*Server).token, false))
+	mux.HandleFunc("/v2/user/get", s.handle((*Server).userInfo, true))
+	mux.HandleFunc("/v2/drive/list_my_drives", s.handle((*Server).listDrives, true))
+	mux.HandleFunc("/v2/file/list", s.handle((*Server).list, true))
+	mux.HandleFunc("/v2/file/get", s.handle((*Server).get, true))
+	mux.HandleFunc("/v2/file/get_by_path", s.handle((*Server).getByPath, true))
+	mux.HandleFunc("/adrive/v1/file/get_path", s.handle((*Server).getPath, true))
+	mux.HandleFunc("/v2/file/get_download_url", s.handle((*Server).downloadURL, true))
+	mux.HandleFunc("/adrive/v2/file/createWithFolders", s.handle((*Server).createWithFolders, true))
+	mux.HandleFunc("/v2/file/complete", s.handle((*Server).complete, true))
+	mux.HandleFunc("/v2/file/get_upload_url", s.handle((*Server).getUploadUrl, true))
+	mux.HandleFunc("/v2/file/list_uploaded_parts", s.handle((*Server).listUploadedParts, true))
+	mux.HandleFunc("/v2/recyclebin/trash", s.handle((*Server).trash, true))
+	mux.HandleFunc("/v2/recyclebin/list", s.handle((*Server).recycleBinList, true))
+	mux.HandleFunc("/v2/recyclebin/restore", s.handle((*Server).restore, true))
+	mux.HandleFunc("/v2/recyclebin/clear", s.handle((*Server).clearRecycleBin, true))
+	mux.HandleFunc("/v3/file/delete", s.handle((*Server).deleteFile, true))
+	mux.HandleFunc("/adrive/v2/share_link/create", s.handle((*Server).createShare, true))
+	mux.HandleFunc("/adrive/v3/share_link/list", s.handle((*Server).listShares, true))
+	mux.HandleFunc("/adrive/v2/share_link/update", s.handle((*Server).updateShare, true))
+	mux.HandleFunc("/adrive/v2/share_link/cancel", s.handle((*Server).cancelShare, true))
+	mux.HandleFunc("/v2/share_link/get_share_token", s.handle((*Server).getShareToken, false))
+	mux.HandleFunc("/adrive/v3/share_link/get_share_by_anonymous", s.handle((*Server).shareInfo, false))
+	mux.HandleFunc("/adrive/v2/file/list_by_share", s.handle((*Server).listByShare, false))
+	mux.HandleFunc("/adrive/v2/file/get_by_share", s.handle((*Server).getByShare, false))
+	mux.HandleFunc("/v2/file/get_share_link_download_url", s.handle((*Server).shareDownloadURL, true))
+	mux.HandleFunc("/v2/file/move", s.handle((*Server).move, true))
+	mux.HandleFunc("/v2/file/copy", s.handle((*Server).copy, true))
+	mux.HandleFunc("/v2/async_task/get", s.handle((*Server).asyncTask, true))
+	mux.HandleFunc("/v2/file/update", s.handle((*Server).update, true))
+	mux.HandleFunc("/v3/batch", s.handle((*Server).batch, true))
+	mux.HandleFunc("/upload/", s.handlePartUpload)
+	mux.HandleFunc("/download/", s.handleDownload)
+
+	return mux
+}
+
+func (s *Server) handle(op operation, auth bool) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			writeJSON(writer, http.StatusBadRequest, &errorBody{Code: "InvalidParameter", Message: err.Error()})
+			return
+		}
+
+		s.mu.Lock()
+
+		var status int
+		var resp interface{}
+
+		s.requestShare = nil
+		s.requestCount++
+		writer.Header().Set("x-ca-request-id", fmt.Sprintf("drivetest-request-%d", s.requestCount))
+
+		if s.throttle > 0 {
+			s.throttle--
+
+			if s.throttleRetryAfter != "" {
+				writer.Header().Set("Retry-After", s.throttleRetryAfter)
+			}
+
+			s.mu.Unlock()
+
+			writeJSON(writer, http.StatusTooManyRequests, &errorBody{Code: "TooManyRequests", Message: "Too Many Requests"})
+			return
+		}
+
+		shareToken := request.Header.Get("x-share-token")
+		sh, shareOk := s.shareByToken(shareToken)
+
+		if auth && !s.authorize(request) {
+			status, resp = newError(http.StatusUnauthorized, models.CodeAccessTokenInvalid,
+				"AccessToken is invalid. ErrValidateTokenFailed")
+		} else if shareToken != "" && !shareOk {
+			status, resp = shareTokenInvalid()
+		} else {
+			s.requestShare = sh
+			status, resp = op(s, body)
+		}
+
+		s.requestShare, s.requestUser, s.requestToken = nil, nil, ""
+		s.mu.Unlock()
+
+		writeJSON(writer, status, resp)
+	}
+}
+
+func writeJSON(writer http.ResponseWriter, status int, resp interface{}) {
+	if status == http.StatusNoContent || resp == nil {
+		writer.WriteHeader(status)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+
+	_ = json.NewEncoder(writer).Encode(resp)
+}
+
+func (s *Server) token(body []byte) (int, interface{}) {
+	var request struct {
+		RefreshToken string `json:"refresh_token"`
+		GrantType    string `json:"grant_type"`
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	if u, ok := s.userByRefreshToken(request.RefreshToken); ok {
+		return s.userToken(u)
+	}
+
+	if request.RefreshToken == "" || request.RefreshToken != s.RefreshToken {
+		return newError(http.StatusBadRequest, "InvalidParameter.RefreshToken",
+			"The input parameter refresh_token is not valid. ")
+	}
+
+	s.tokenSeq++
+	s.accessToken = fmt.Sprintf("drivetest-access-token-%d", s.tokenSeq)
+
+	return http.StatusOK, map[string]interface{}{
+		"access_token":          s.accessToken,
+		"refresh_token":         s.RefreshToken,
+		"expires_in":            7200,
+		"token_type":            "Bearer",
+		"user_id":               s.UserId,
+		"user_name":             s.UserId,
+		"nick_name":             s.NickName,
+		"default_drive_id":      s.DriveId,
+		"default_sbox_drive_id": s.SboxDriveId,
+		"domain_id":             DefaultDomainId,
+		"status":                "enabled",
+		"role":                  "user",
+	}
+}
+
+func (s *Server) userInfo(body []byte) (int, interface{}) {
+	info := &models.UserInfo{
+		DomainId:       DefaultDomainId,
+		UserId:         s.UserId,
+		UserName:       s.UserId,
+		NickName:       s.NickName,
+		DefaultDriveId: s.DriveId,
+		Role:           "user",
+		Status:         "enabled",
+	}
+
+	if u := s.requestUser; u != nil {
+		info.UserId, info.UserName, info.NickName, info.DefaultDriveId = u.UserId, u.UserId, u.UserId, u.DriveId
+	}
+
+	return http.StatusOK, info
+}
+
+func (s *Server) listDrives(body []byte) (int, interface{}) {
+	if s.requestUser != nil {
+		return s.userDrives(s.requestUser)
+	}
+
+	return http.StatusOK, &models.ListDrivesResponse{
+		Items: []*models.Drive{
+			{
+				DriveId:   s.DriveId,
+				DriveName: "Default",
+				DriveType: "normal",
+				Category:  "default",
+				Owner:     s.UserId,
+				OwnerType: "user",
+				Status:    "enabled",
+			},
+			{
+				DriveId:   s.SboxDriveId,
+				DriveName: "sbox",
+				DriveType: "normal",
+				Category:  "sbox",
+				Owner:     s.UserId,
+				OwnerType: "user",
+				Status:    "enabled",
+			},
+		},
+	}
+}
+
+func (s *Server) list(body []byte) (int, interface{}) {
+	var request models.FolderFilesRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	if _, ok := s.lookup(request.DriveId, request.ParentFileId); !ok {
+		return notFound(request.ParentFileId)
+	}
+
+	items := s.children(request.DriveId, request.ParentFileId)
+	sortEntries(items, request.OrderBy, request.OrderDirection)
+
+	return paginate(items, request.Marker, request.Limit)
+}
+
+// pageRange 将 marker 解析为偏移量，返回当前页范围和下一页 marker
+func pageRange(total int, marker string, limit int) (offset, end int, nextMarker string, ok bool) {
+	if marker != "" {
+		var err error
+
+		offset, err = strconv.Atoi(marker)
+		if err != nil || offset < 0 || offset > total {
+			return 0, 0, "", false
+		}
+	}
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	end = offset + limit
+	nextMarker = strconv.Itoa(end)
+
+	if end >= total {
+		end = total
+		nextMarker = ""
+	}
+
+	return offset, end, nextMarker, true
+}
+
+// paginate 按 marker 偏移量分页返回文件列表
+func paginate(items []*entry, marker string, limit int) (int, interface{}) {
+	offset, end, nextMarker, ok := pageRange(len(items), marker, limit)
+	if !ok {
+		return badRequest("invalid marker: " + marker)
+	}
+
+	result := models.Files{
+		Items:      []*models.File{},
+		NextMarker: nextMarker,
+	}
+
+	for _, e := range items[offset:end] {
+		result.Items = append(result.Items, copyFile(e.file))
+	}
+
+	return http.StatusOK, &result
+}
+
+func sortEntries(items []*entry, orderBy, direction string) {
+	less := func(a, b *entry) bool {
+		switch orderBy {
+		case "name":
+			return a.file.Name < b.file.Name
+		case "size":
+			return a.file.Size < b.file.Size
+		case "created_at":
+			return a.file.CreatedAt.Before(b.file.CreatedAt)
+		default:
+			return a.file.UpdatedAt.Before(b.file.UpdatedAt)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if direction == models.OrderDirectionTypeDescend {
+			return less(items[j], items[i])
+		}
+
+		return less(items[i], items[j])
+	})
+}
+
+func (s *Server) get(body []byte) (int, interface{}) {
+	var request models.FileRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	e, ok := s.lookup(request.DriveId, request.FileId)
+	if !ok {
+		return notFound(request.FileId)
+	}
+
+	return http.StatusOK, copyFile(e.file)
+}
+
+func (s *Server) getByPath(body []byte) (int, interface{}) {
+	var request models.GetFileByPathRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	e, ok := s.lookup(request.DriveId, rootFileId)
+	if !ok {
+		return notFound(rootFileId)
+	}
+
+	for _, name := range strings.Split(path.Clean("/"+request.FilePath), "/") {
+		if name == "" {
+			continue
+		}
+
+		if e, ok = s.findChild(request.DriveId, e.file.FileId, name); !ok {
+			return notFound(request.FilePath)
+		}
+	}
+
+	return http.StatusOK, copyFile(e.file)
+}
+
+func (s *Server) getPath(body []byte) (int, interface{}) {
+	var request models.GetPathRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	e, ok := s.lookup(request.DriveId, request.FileId)
+	if !ok {
+		return notFound(request.FileId)
+	}
+
+	items := []*models.File{}
+
+	for e.file.FileId != rootFileId {
+		items = append(items, copyFile(e.file))
+
+		if e, ok = s.lookup(request.DriveId, e.file.ParentFileId); !ok {
+			return notFound(request.FileId)
+		}
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"items": items,
+	}
+}
+
+func (s *Server) downloadURL(body []byte) (int, interface{}) {
+	var request models.DownloadURLRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	e, ok := s.lookup(request.DriveId, request.FileId)
+	if !ok || e.file.Type != models.FileTypeFile {
+		return notFound(request.FileId)
+	}
+
+	expireSec := request.ExpireSec
+	if expireSec <= 0 {
+		expireSec = 900
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"url":        fmt.Sprintf("%s/download/%s/%s?x-oss-expires=%d", s.URL, request.DriveId, request.FileId, s.downloadUrlSeq),
+		"expiration": time.Now().UTC().Add(time.Duration(expireSec) * time.Second).Format("2006-01-02T15:04:05.000Z"),
+		"method":     http.MethodGet,
+		"size":       e.file.Size,
+	}
+}
+
+type createWithFoldersRequest struct {
+	models.CreateWithFolders
+
+	PreHash         string `json:"pre_hash"`
+	ContentHash     string `json:"content_hash"`
+	ContentHashName string `json:"content_hash_name"`
+	ProofCode       string `json:"proof_code"`
+	ProofVersion    string `json:"proof_version"`
+}
+
+func (s *Server) createWithFolders(body []byte) (int, interface{}) {
+	var request createWithFoldersRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	parent, ok := s.lookup(request.DriveId, request.ParentFileId)
+	if !ok || parent.file.Type != models.FileTypeFolder {
+		return notFound(request.ParentFileId)
+	}
+
+	name := request.Name
+
+	if existed, ok := s.findChild(request.DriveId, request.ParentFileId, name); ok {
+		switch request.CheckNameMode {
+		case models.CheckNameModeRefuse:
+			resp := createResponse(existed)
+			resp["exist"] = true
+
+			return http.StatusOK, resp
+		case models.CheckNameModeAutoRename:
+			name = s.availableName(request.DriveId, request.ParentFileId, name)
+		}
+	}
+
+	if request.Type == models.FileTypeFolder {
+		e := s.newEntry(request.DriveId, request.ParentFileId, name, models.FileTypeFolder)
+
+		return http.StatusCreated, createResponse(e)
+	}
+
+	if request.PreHash != "" {
+		for _, e := range s.files {
+			if e.file.Type == models.FileTypeFile && e.file.Size == request.Size &&
+				!e.file.Trashed && e.file.Status == models.FileStatusAvailable &&
+				strings.EqualFold(e.preHash(), request.PreHash) {
+				return newError(http.StatusConflict, models.CodePreHashMatched, "Pre hash matched.")
+			}
+		}
+	}
+
+	if request.ContentHash != "" && !s.rapidUploadDisabled {
+		for _, source := range s.files {
+			if source.file.Type != models.FileTypeFile || source.file.Size != request.Size || source.file.Trashed ||
+				!strings.EqualFold(source.file.ContentHash, request.ContentHash) {
+				continue
+			}
+
+			if request.ProofCode != proofCode(s.requestToken, source.content) {
+				return newError(http.StatusBadRequest, models.CodeInvalidProofCode,
+					"The input parameter proof_code is not valid. ")
+			}
+
+			e := s.newEntry(request.DriveId, request.ParentFileId, name, models.FileTypeFile)
+			e.setContent(source.content)
+
+			resp := createResponse(e)
+			resp["rapid_upload"] = true
+
+			return http.StatusCreated, resp
+		}
+	}
+
+	e := s.newEntry(request.DriveId, request.ParentFileId, name, models.FileTypeFile)
+	e.file.Size = request.Size
+	e.file.Status = "uploading"
+
+	uploadId := fmt.Sprintf("drivetest-upload-%d", e.seq)
+	s.uploads[uploadId] = &upload{
+		driveId: request.DriveId,
+		fileId:  e.file.FileId,
+		parts:   make(map[int][]byte),
+	}
+
+	resp := createResponse(e)
+	resp["upload_id"] = uploadId
+	resp["rapid_upload"] = false
+	resp["part_info_list"] = s.partInfoList(uploadId, request.PartInfoList)
+
+	return http.StatusCreated, resp
+}
+
+func createResponse(e *entry) map[string]interface{} {
+	return map[string]interface{}{
+		"parent_file_id": e.file.ParentFileId,
+		"type":           e.file.Type,
+		"file_id":        e.file.FileId,
+		"domain_id":      e.file.DomainId,
+		"drive_id":       e.file.DriveId,
+		"file_name":      e.file.Name,
+		"encrypt_mode":   "none",
+		"location":       "cn-hangzhou",
+	}
+}
+
+func (s *Server) partInfoList(uploadId string, parts []*models.PartInfo) []map[string]interface{} {
+	result := []map[string]interface{}{}
+
+	for _, part := range parts {
+		uploadUrl := fmt.Sprintf("%s/upload/%s/%d?x-oss-expires=%d", s.URL, uploadId, part.PartNumber, s.uploadUrlSeq)
+
+		result = append(result, map[string]interface{}{
+			"part_number":         part.PartNumber,
+			"part_size":           part.PartSize,
+			"upload_url":          uploadUrl,
+			"internal_upload_url": uploadUrl,
+			"content_type":        "",
+		})
+	}
+
+	return result
+}
+
+// handlePartUpload 处理分片上传 PUT /upload/{uploadId}/{partNumber}
+func (s *Server) handlePartUpload(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPut {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := strings.Split(strings.TrimPrefix(request.URL.Path, "/upload/"), "/")
+	if len(segments) != 2 {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	partNumber, err := strconv.Atoi(segments[1])
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if seq, err := strconv.Atoi(request.URL.Query().Get("x-oss-expires")); err != nil || seq < s.uploadUrlSeq {
+		writer.Header().Set("Content-Type", "application/xml")
+		writer.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(writer, `<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+  <Code>AccessDenied</Code>
+  <Message>Request has expired.</Message>
+</Error>`)
+
+		return
+	}
+
+	u, ok := s.uploads[segments[0]]
+	if !ok {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	u.parts[partNumber] = body
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+// findUpload 查找未完成的分片上传
+func (s *Server) findUpload(driveId, fileId, uploadId string) (*upload, bool) {
+	u, ok := s.uploads[uploadId]
+	if !ok || u.fileId != fileId || u.driveId != driveId {
+		return nil, false
+	}
+
+	return u, true
+}
+
+func uploadNotFound() (int, interface{}) {
+	return newError(http.StatusNotFound, "NotFound.UploadId", "The resource upload_id cannot be found.")
+}
+
+func (s *Server) getUploadUrl(body []byte) (int, interface{}) {
+	var request models.GetUploadUrlRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	if _, ok := s.findUpload(request.DriveId, request.FileId, request.UploadId); !ok {
+		return uploadNotFound()
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"domain_id":      DefaultDomainId,
+		"drive_id":       request.DriveId,
+		"file_id":        request.FileId,
+		"upload_id":      request.UploadId,
+		"part_info_list": s.partInfoList(request.UploadId, request.PartInfoList),
+	}
+}
+
+func (s *Server) listUploadedParts(body []byte) (int, interface{}) {
+	var request models.ListUploadedPartsRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	u, ok := s.findUpload(request.DriveId, request.FileId, request.UploadId)
+	if !ok {
+		return uploadNotFound()
+	}
+
+	numbers := make([]int, 0, len(u.parts))
+	for number := range u.parts {
+		if number > request.PartNumberMarker {
+			numbers = append(numbers, number)
+		}
+	}
+
+	sort.Ints(numbers)
+
+	parts := []map[string]interface{}{}
+
+	for _, number := range numbers {
+		parts = append(parts, map[string]interface{}{
+			"part_number": number,
+			"part_size":   len(u.parts[number]),
+			"etag":        fmt.Sprintf("drivetest-etag-%d", number),
+		})
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"file_id":                 request.FileId,
+		"upload_id":               request.UploadId,
+		"parallel_upload":         false,
+		"uploaded_parts":          parts,
+		"next_part_number_marker": "",
+	}
+}
+
+func (s *Server) complete(body []byte) (int, interface{}) {
+	var request models.CompleteFileUploadRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	u, ok := s.findUpload(request.DriveId, request.FileId, request.UploadId)
+	if !ok {
+		return uploadNotFound()
+	}
+
+	e := s.files[fileKey(u.driveId, u.fileId)]
+
+	numbers := make([]int, 0, len(u.parts))
+	for number := range u.parts {
+		numbers = append(numbers, number)
+	}
+
+	sort.Ints(numbers)
+
+	var content bytes.Buffer
+
+	for i, number := range numbers {
+		if number != i+1 {
+			return newError(http.StatusBadRequest, "PartNotSequential", "part number is not sequential")
+		}
+
+		content.Write(u.parts[number])
+	}
+
+	if int64(content.Len()) != e.file.Size {
+		return newError(http.StatusBadRequest, "SizeCheckFailed",
+			fmt.Sprintf("size not match, expect %d, actual %d", e.file.Size, content.Len()))
+	}
+
+	e.setContent(content.Bytes())
+	e.file.Status = models.FileStatusAvailable
+
+	delete(s.uploads, request.UploadId)
+
+	resp := struct {
+		*models.File
+		UploadId string `json:"upload_id"`
+	}{
+		File:     copyFile(e.file),
+		UploadId: request.UploadId,
+	}
+
+	return http.StatusOK, &resp
+}
+
+func (s *Server) trash(body []byte) (int, interface{}) {
+	var request models.RemoveFileRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	e, ok := s.lookup(request.DriveId, request.FileId)
+	if !ok || request.FileId == rootFileId {
+		return notFound(request.FileId)
+	}
+
+	e.file.Trashed = true
+
+	return http.StatusNoContent, nil
+}
+
+// trashed 返回被直接放入回收站的文件，已删除目录下的文件不单独列出
+func (s *Server) trashed(driveId string) []*entry {
+	var result []*entry
+
+	for _, e := range s.files {
+		if e.file.DriveId != driveId || !e.file.Trashed {
+			continue
+		}
+
+		if parent, ok := s.files[fileKey(driveId, e.file.ParentFileId)]; ok && parent.file.Trashed {
+			continue
+		}
+
+		result = append(result, e)
+	}
+
+	return result
+}
+
+func (s *Server) recycleBinList(body []byte) (int, interface{}) {
+	var request models.RecycleBinListRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	items := s.trashed(request.DriveId)
+	sortEntries(items, request.OrderBy, request.OrderDirection)
+
+	return paginate(items, request.Marker, request.Limit)
+}
+
+func (s *Server) restore(body []byte) (int, interface{}) {
+	var request models.RecycleBinFileRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	e, ok := s.files[fileKey(request.DriveId, request.FileId)]
+	if !ok || !e.file.Trashed {
+		return notFound(request.FileId)
+	}
+
+	if _, ok := s.lookup(request.DriveId, e.file.ParentFileId); !ok {
+		return notFound(e.file.ParentFileId)
+	}
+
+	if _, exist := s.findChild(request.DriveId, e.file.ParentFileId, e.file.Name); exist {
+		e.file.Name = s.availableName(request.DriveId, e.file.ParentFileId, e.file.Name)
+	}
+
+	e.file.Trashed = false
+
+	if e.file.Type == models.FileTypeFolder {
+		return http.StatusAccepted, s.newTask(e)
+	}
+
+	return http.StatusNoContent, nil
+}
+
+func (s *Server) deleteFile(body []byte) (int, interface{}) {
+	var request models.RecycleBinFileRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	e, ok := s.files[fileKey(request.DriveId, request.FileId)]
+	if !ok || request.FileId == rootFileId {
+		return notFound(request.FileId)
+	}
+
+	s.deleteTree(e)
+
+	if e.file.Type == models.FileTypeFolder {
+		return http.StatusAccepted, s.newTask(e)
+	}
+
+	return http.StatusNoContent, nil
+}
+
+func (s *Server) clearRecycleBin(body []byte) (int, interface{}) {
+	var request models.ClearRecycleBinRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	var last *entry
+
+	for _, e := range s.trashed(request.DriveId) {
+		s.deleteTree(e)
+		last = e
+	}
+
+	if last == nil {
+		return http.StatusNoContent, nil
+	}
+
+	return http.StatusAccepted, s.newTask(last)
+}
+
+// deleteTree 从存储中移除文件或目录及其所有子文件
+func (s *Server) deleteTree(e *entry) {
+	for _, child := range s.files {
+		if child.file.DriveId == e.file.DriveId && child.file.ParentFileId == e.file.FileId &&
+			child.file.FileId != rootFileId {
+			s.deleteTree(child)
+		}
+	}
+
+	delete(s.files, fileKey(e.file.DriveId, e.file.FileId))
+}
+
+// newTask 创建异步任务，第一次查询时返回运行中
+func (s *Server) newTask(e *entry) map[string]interface{} {
+	taskId := fmt.Sprintf("drivetest-task-%d", e.seq)
+
+	s.tasks[taskId] = 1
+
+	return map[string]interface{}{
+		"domain_id":     e.file.DomainId,
+		"drive_id":      e.file.DriveId,
+		"file_id":       e.file.FileId,
+		"async_task_id": taskId,
+	}
+}
+
+func (s *Server) move(body []byte) (int, interface{}) {
+	var request models.MoveFileRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	e, ok := s.lookup(request.DriveId, request.FileId)
+	if !ok || request.FileId == rootFileId {
+		return notFound(request.FileId)
+	}
+
+	toDriveId := request.ToDriveId
+	if toDriveId == "" {
+		toDriveId = request.DriveId
+	}
+
+	if toDriveId != request.DriveId {
+		return badRequest("move across drives is not supported")
+	}
+
+	target, ok := s.lookup(toDriveId, request.ToParentFileId)
+	if !ok || target.file.Type != models.FileTypeFolder {
+		return notFound(request.ToParentFileId)
+	}
+
+	if s.isAncestor(e, target) {
+		return badRequest("cannot move a folder into itself")
+	}
+
+	e.file.ParentFileId = request.ToParentFileId
+	e.file.UpdatedAt = time.Now()
+
+	return http.StatusOK, map[string]interface{}{
+		"domain_id": e.file.DomainId,
+		"drive_id":  e.file.DriveId,
+		"file_id":   e.file.FileId,
+	}
+}
+
+// copyRequest 复制文件请求，设置 ShareId 时为转存分享中的文件
+type copyRequest struct {
+	models.CopyFileRequest
+
+	ShareId string `json:"share_id"`
+}
+
+func (s *Server) copy(body []byte) (int, interface{}) {
+	var request copyRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	// 转存分享中的文件时，源文件位于分享者的 Drive
+	if request.ShareId != "" {
+		sh, ok := s.currentShare(request.ShareId)
+		if !ok {
+			return shareTokenInvalid()
+		}
+
+		if _, ok := s.sharedEntry(sh, request.FileId); !ok {
+			return notFound(request.FileId)
+		}
+
+		request.DriveId = sh.link.DriveId
+	}
+
+	e, ok := s.lookup(request.DriveId, request.FileId)
+	if !ok || request.FileId == rootFileId {
+		return notFound(request.FileId)
+	}
+
+	toDriveId := request.ToDriveId
+	if toDriveId == "" {
+		toDriveId = request.DriveId
+	}
+
+	target, ok := s.lookup(toDriveId, request.ToParentFileId)
+	if !ok || target.file.Type != models.FileTypeFolder {
+		return notFound(request.ToParentFileId)
+	}
+
+	if s.isAncestor(e, target) {
+		return badRequest("cannot copy a folder into itself")
+	}
+
+	name := e.file.Name
+	if request.NewName != "" {
+		name = request.NewName
+	}
+
+	if _, exist := s.findChild(toDriveId, request.ToParentFileId, name); exist {
+		if !request.AutoRename {
+			return newError(http.StatusConflict, "AlreadyExist.File",
+				"The resource file has already exists. file already exist")
+		}
+
+		name = s.availableName(toDriveId, request.ToParentFileId, name)
+	}
+
+	copied := s.copyTree(e, toDriveId, request.ToParentFileId, name)
+
+	// 目录复制在服务端异步执行
+	if copied.file.Type == models.FileTypeFolder {
+		return http.StatusAccepted, s.newTask(copied)
+	}
+
+	return http.StatusCreated, map[string]interface{}{
+		"domain_id": copied.file.DomainId,
+		"drive_id":  copied.file.DriveId,
+		"file_id":   copied.file.FileId,
+	}
+}
+
+func (s *Server) asyncTask(body []byte) (int, interface{}) {
+	var request models.AsyncTaskRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	remain, ok := s.tasks[request.AsyncTaskId]
+	if !ok {
+		return newError(http.StatusNotFound, "NotFound.AsyncTask", "The resource async task cannot be found.")
+	}
+
+	state := models.AsyncTaskStateSucceed
+
+	if remain > 0 {
+		s.tasks[request.AsyncTaskId] = remain - 1
+		state = models.AsyncTaskStateRunning
+	}
+
+	return http.StatusOK, &models.AsyncTaskResponse{
+		AsyncTaskId: request.AsyncTaskId,
+		State:       state,
+	}
+}
+
+// isAncestor folder 是否为 e 本身或 e 的上级目录
+func (s *Server) isAncestor(folder, e *entry) bool {
+	for p := e; p != nil; p = s.files[fileKey(p.file.DriveId, p.file.ParentFileId)] {
+		if p == folder {
+			return true
+		}
+
+		if p.file.FileId == rootFileId {
+			break
+		}
+	}
+
+	return false
+}
+
+// copyTree 递归复制文件或目录
+func (s *Server) copyTree(source *entry, driveId, parentFileId, name string) *entry {
+	e := s.newEntry(driveId, parentFileId, name, source.file.Type)
+
+	if source.file.Type == models.FileTypeFile {
+		e.setContent(source.content)
+		return e
+	}
+
+	for _, child := range s.children(source.file.DriveId, source.file.FileId) {
+		s.copyTree(child, driveId, e.file.FileId, child.file.Name)
+	}
+
+	return e
+}
+
+func (s *Server) update(body []byte) (int, interface{}) {
+	var request models.RenameFileRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	e, ok := s.lookup(request.DriveId, request.FileId)
+	if !ok || request.FileId == rootFileId {
+		return notFound(request.FileId)
+	}
+
+	if request.Name != "" && request.Name != e.file.Name {
+		if _, exist := s.findChild(request.DriveId, e.file.ParentFileId, request.Name); exist &&
+			request.CheckNameMode == models.CheckNameModeRefuse {
+			return newError(http.StatusConflict, "AlreadyExist.File",
+				"The resource file has already exists. file already exist")
+		}
+
+		e.file.Name = request.Name
+		e.file.UpdatedAt = time.Now()
+	}
+
+	return http.StatusOK, copyFile(e.file)
+}
+
+type batchRequest struct {
+	Requests []*struct {
+		Body   json.RawMessage `json:"body"`
+		Id     string          `json:"id"`
+		Method string          `json:"method"`
+		Url    string          `json:"url"`
+	} `json:"requests"`
+	Resource string `json:"resource"`
+}
+
+type batchResponseItem struct {
+	Body   interface{} `json:"body,omitempty"`
+	Id     string      `json:"id"`
+	Status int         `json:"status"`
+}
+
+func (s *Server) batch(body []byte) (int, interface{}) {
+	var request batchRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	responses := []*batchResponseItem{}
+
+	for _, item := range request.Requests {
+		op, ok := batchOperations[item.Url]
+		if !ok {
+			status, resp := newError(http.StatusNotFound, "NotFound.Url", "unsupported batch url: "+item.Url)
+
+			responses = append(responses, &batchResponseItem{Id: item.Id, Status: status, Body: resp})
+			continue
+		}
+
+		status, resp := op(s, item.Body)
+
+		responses = append(responses, &batchResponseItem{Id: item.Id, Status: status, Body: resp})
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"responses": responses,
+	}
+}
+
+// handleDownload 处理文件下载 GET /download/{driveId}/{fileId}，支持 Range
+func (s *Server) handleDownload(writer http.ResponseWriter, request *http.Request) {
+	segments := strings.Split(strings.TrimPrefix(request.URL.Path, "/download/"), "/")
+	if len(segments) != 2 {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	e, ok := s.lookup(segments[0], segments[1])
+	expired := request.URL.Query().Get("x-oss-expires") != strconv.Itoa(s.downloadUrlSeq)
+
+	var content []byte
+	var updatedAt time.Time
+
+	if ok {
+		content = e.content
+		updatedAt = e.file.UpdatedAt
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if expired {
+		writer.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	http.ServeContent(writer, request, segments[1], updatedAt, bytes.NewReader(content))
+}
@@ -0,0 +1,430 @@
+// Package drivetest 提供基于 httptest 的阿里云盘模拟服务，使用内存中的文件树实现常用接口，
+// 用于在没有 RefreshToken 和网络的环境下测试 SDK。
+package drivetest
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"github.com/jakeslee/aliyundrive/models"
+	"hash/crc64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRefreshToken = "drivetest-refresh-token"
+	DefaultUserId       = "drivetest-user"
+	DefaultNickName     = "drivetest"
+	DefaultDriveId      = "10001"
+	DefaultSboxDriveId  = "10002"
+	DefaultDomainId     = "drivetest"
+	rootFileId          = "root"
+)
+
+// Server 模拟阿里云盘 API 服务
+type Server struct {
+	*httptest.Server
+
+	RefreshToken string
+	UserId       string
+	NickName     string
+	DriveId      string
+	SboxDriveId  string
+
+	mu             sync.Mutex
+	accessToken    string
+	tokenSeq       int
+	fileSeq        int
+	files          map[string]*entry
+	uploads        map[string]*upload
+	uploadUrlSeq   int            // 分片上传地址的版本，小于该版本的地址已过期
+	downloadUrlSeq int            // 下载地址的版本，小于该版本的地址已过期
+	tasks          map[string]int // 异步任务 ID 到完成前剩余的查询次数
+	shareSeq       int
+	shares         map[string]*share
+	shareTokens    map[string]*shareToken
+
+	// requestShare 当前请求 x-share-token 对应的分享，仅在处理请求并持有 mu 时有效
+	requestShare *share
+
+	users               []*User
+	rapidUploadDisabled bool
+	// requestUser 和 requestToken 当前请求的用户和 AccessToken，默认用户的 requestUser 为 nil
+	requestUser  *User
+	requestToken string
+
+	// throttle 之后的 API 请求中返回限流错误的次数，throttleRetryAfter 为响应的 Retry-After 头
+	throttle           int
+	throttleRetryAfter string
+	requestCount       int
+}
+
+type entry struct {
+	file    *models.File
+	content []byte
+	seq     int
+}
+
+type upload struct {
+	driveId string
+	fileId  string
+	parts   map[int][]byte
+}
+
+// NewServer 创建并启动模拟服务，使用完毕后需要调用 Close
+func NewServer() *Server {
+	s := &Server{
+		RefreshToken: DefaultRefreshToken,
+		UserId:       DefaultUserId,
+		NickName:     DefaultNickName,
+		DriveId:      DefaultDriveId,
+		SboxDriveId:  DefaultSboxDriveId,
+		files:        make(map[string]*entry),
+		uploads:      make(map[string]*upload),
+		tasks:        make(map[string]int),
+		shares:       make(map[string]*share),
+		shareTokens:  make(map[string]*shareToken),
+	}
+
+	for _, driveId := range []string{s.DriveId, s.SboxDriveId} {
+		s.files[fileKey(driveId, rootFileId)] = &entry{
+			file: &models.File{
+				DriveId:   driveId,
+				DomainId:  DefaultDomainId,
+				FileId:    rootFileId,
+				Name:      rootFileId,
+				Type:      models.FileTypeFolder,
+				Status:    models.FileStatusAvailable,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			},
+		}
+	}
+
+	s.Server = httptest.NewServer(s.routes())
+
+	return s
+}
+
+// Transport 返回将所有请求转发到模拟服务的 RoundTripper，可作为 aliyundrive.Options 的 Transport
+func (s *Server) Transport() http.RoundTripper {
+	target, _ := url.Parse(s.URL)
+
+	return &rewriteTransport{
+		target: target,
+		base:   s.Client().Transport,
+	}
+}
+
+// ExpireAccessToken 使当前 AccessToken 失效，用于测试自动刷新 Token
+func (s *Server) ExpireAccessToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accessToken = ""
+}
+
+// ExpireUploadUrls 使已下发的分片上传地址全部过期，用于测试上传中途刷新地址
+func (s *Server) ExpireUploadUrls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.uploadUrlSeq++
+}
+
+// ExpireDownloadUrls 使已下发的下载地址全部过期，用于测试下载地址刷新
+func (s *Server) ExpireDownloadUrls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.downloadUrlSeq++
+}
+
+// Throttle 使之后的 n 个 API 请求返回 429 限流错误，retryAfter 大于 0 时设置 Retry-After 头，用于测试重试
+func (s *Server) Throttle(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.throttle = n
+	s.throttleRetryAfter = ""
+
+	if retryAfter > 0 {
+		s.throttleRetryAfter = strconv.Itoa(int(retryAfter / time.Second))
+	}
+}
+
+// RequestCount 返回收到的 API 请求数，不包括分片上传和下载
+func (s *Server) RequestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requestCount
+}
+
+// PendingUploads 返回已创建但未完成上传的文件数量
+func (s *Server) PendingUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+
+	for _, e := range s.files {
+		if e.file.Type == models.FileTypeFile && e.file.Status == "uploading" {
+			count++
+		}
+	}
+
+	return count
+}
+
+// DisableRapidUpload 使秒传总是失败，用于测试秒传失败后的回退
+func (s *Server) DisableRapidUpload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rapidUploadDisabled = true
+}
+
+// AccessToken 返回当前有效的 AccessToken
+func (s *Server) AccessToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accessToken
+}
+
+// AddFolder 在默认 Drive 的 parentFileId 目录下创建目录
+func (s *Server) AddFolder(parentFileId, name string) *models.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.newEntry(s.DriveId, parentFileId, name, models.FileTypeFolder)
+
+	return copyFile(e.file)
+}
+
+// AddFile 在默认 Drive 的 parentFileId 目录下创建内容为 content 的文件
+func (s *Server) AddFile(parentFileId, name string, content []byte) *models.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.newEntry(s.DriveId, parentFileId, name, models.FileTypeFile)
+	e.setContent(content)
+
+	return copyFile(e.file)
+}
+
+// AddDriveFile 在 driveId 的 parentFileId 目录下创建内容为 content 的文件，如保险箱 SboxDriveId
+func (s *Server) AddDriveFile(driveId, parentFileId, name string, content []byte) *models.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.newEntry(driveId, parentFileId, name, models.FileTypeFile)
+	e.setContent(content)
+
+	return copyFile(e.file)
+}
+
+// File 返回默认 Drive 中 fileId 对应的文件信息，文件被删除到回收站时返回 false
+func (s *Server) File(fileId string) (*models.File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.files[fileKey(s.DriveId, fileId)]
+	if !ok || e.file.Trashed {
+		return nil, false
+	}
+
+	return copyFile(e.file), true
+}
+
+// Content 返回默认 Drive 中 fileId 对应的文件内容
+func (s *Server) Content(fileId string) ([]byte, bool) {
+	return s.DriveContent(s.DriveId, fileId)
+}
+
+// DriveContent 返回 driveId 中 fileId 对应的文件内容
+func (s *Server) DriveContent(driveId, fileId string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.files[fileKey(driveId, fileId)]
+	if !ok || e.file.Trashed {
+		return nil, false
+	}
+
+	return append([]byte(nil), e.content...), true
+}
+
+// Children 返回默认 Drive 中 parentFileId 目录下的文件，按创建顺序排列
+func (s *Server) Children(parentFileId string) []*models.File {
+	return s.DriveChildren(s.DriveId, parentFileId)
+}
+
+// DriveChildren 返回 driveId 中 parentFileId 目录下的文件，按创建顺序排列
+func (s *Server) DriveChildren(driveId, parentFileId string) []*models.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []*models.File
+
+	for _, e := range s.children(driveId, parentFileId) {
+		result = append(result, copyFile(e.file))
+	}
+
+	return result
+}
+
+func (s *Server) newEntry(driveId, parentFileId, name string, fileType models.FileType) *entry {
+	s.fileSeq++
+
+	now := time.Now()
+	e := &entry{
+		seq: s.fileSeq,
+		file: &models.File{
+			DriveId:      driveId,
+			DomainId:     DefaultDomainId,
+			FileId:       fmt.Sprintf("%040x", s.fileSeq),
+			Name:         name,
+			Type:         fileType,
+			ParentFileId: parentFileId,
+			Status:       models.FileStatusAvailable,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		},
+	}
+
+	s.files[fileKey(driveId, e.file.FileId)] = e
+
+	return e
+}
+
+func (s *Server) lookup(driveId, fileId string) (*entry, bool) {
+	e, ok := s.files[fileKey(driveId, fileId)]
+	if !ok || e.file.Trashed || e.file.Status != models.FileStatusAvailable {
+		return nil, false
+	}
+
+	return e, true
+}
+
+func (s *Server) children(driveId, parentFileId string) []*entry {
+	var result []*entry
+
+	for _, e := range s.files {
+		if e.file.DriveId == driveId && e.file.ParentFileId == parentFileId && e.file.FileId != rootFileId &&
+			!e.file.Trashed && e.file.Status == models.FileStatusAvailable {
+			result = append(result, e)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].seq < result[j].seq
+	})
+
+	return result
+}
+
+func (s *Server) findChild(driveId, parentFileId, name string) (*entry, bool) {
+	for _, e := range s.children(driveId, parentFileId) {
+		if e.file.Name == name {
+			return e, true
+		}
+	}
+
+	return nil, false
+}
+
+// availableName 按 auto_rename 规则生成不冲突的文件名
+func (s *Server) availableName(driveId, parentFileId, name string) string {
+	if _, ok := s.findChild(driveId, parentFileId, name); !ok {
+		return name
+	}
+
+	ext := ""
+	base := name
+
+	if i := strings.LastIndex(name, "."); i > 0 {
+		base, ext = name[:i], name[i:]
+	}
+
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s(%d)%s", base, n, ext)
+
+		if _, ok := s.findChild(driveId, parentFileId, candidate); !ok {
+			return candidate
+		}
+	}
+}
+
+func (e *entry) setContent(content []byte) {
+	e.content = content
+	e.file.Size = int64(len(content))
+	e.file.ContentHash = strings.ToUpper(fmt.Sprintf("%x", sha1.Sum(content)))
+	e.file.ContentHashName = "sha1"
+	e.file.Crc64Hash = strconv.FormatUint(crc64.Checksum(content, crc64.MakeTable(crc64.ECMA)), 10)
+	e.file.UpdatedAt = time.Now()
+}
+
+func (e *entry) preHash() string {
+	n := len(e.content)
+	if n > 1024 {
+		n = 1024
+	}
+
+	return fmt.Sprintf("%x", sha1.Sum(e.content[:n]))
+}
+
+// proofCode 与 SDK 的 ComputeProofCodeV1 使用相同算法计算 proof code
+func proofCode(accessToken string, content []byte) string {
+	size := int64(len(content))
+	if size == 0 {
+		return ""
+	}
+
+	hashed := fmt.Sprintf("%x", md5.Sum([]byte(accessToken)))[0:16]
+	hashedInt, _ := new(big.Int).SetString(hashed, 16)
+
+	start := hashedInt.Mod(hashedInt, big.NewInt(size)).Int64()
+	end := start + 8
+
+	if end > size {
+		end = size
+	}
+
+	return base64.StdEncoding.EncodeToString(content[start:end])
+}
+
+func fileKey(driveId, fileId string) string {
+	return driveId + "/" + fileId
+}
+
+func copyFile(file *models.File) *models.File {
+	f := *file
+
+	return &f
+}
+
+type rewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (t *rewriteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	r := request.Clone(request.Context())
+
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	r.Host = t.target.Host
+
+	return t.base.RoundTrip(r)
+}
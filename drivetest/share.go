@@ -0,0 +1,448 @@
+package drivetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jakeslee/aliyundrive/models"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type share struct {
+	link      *models.ShareLink
+	cancelled bool
+	seq       int
+}
+
+// Share 返回分享链接信息，已取消的分享返回 false
+func (s *Server) Share(shareId string) (*models.ShareLink, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sh, ok := s.shares[shareId]
+	if !ok || sh.cancelled {
+		return nil, false
+	}
+
+	return sh.response(), true
+}
+
+// response 返回分享链接的副本，并根据过期时间计算 Expired
+func (sh *share) response() *models.ShareLink {
+	link := *sh.link
+	link.FileIdList = append([]string(nil), sh.link.FileIdList...)
+
+	if t, ok := link.ExpireTime(); ok && t.Before(time.Now()) {
+		link.Expired = true
+	}
+
+	return &link
+}
+
+func (s *Server) createShare(body []byte) (int, interface{}) {
+	var request models.CreateShareRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	if len(request.FileIdList) == 0 {
+		return badRequest("file_id_list is empty")
+	}
+
+	var first *entry
+
+	for _, fileId := range request.FileIdList {
+		e, ok := s.lookup(request.DriveId, fileId)
+		if !ok || fileId == rootFileId {
+			return notFound(fileId)
+		}
+
+		if first == nil {
+			first = e
+		}
+	}
+
+	if request.Expiration != "" {
+		if _, ok := (&models.ShareLink{Expiration: request.Expiration}).ExpireTime(); !ok {
+			return badRequest("invalid expiration: " + request.Expiration)
+		}
+	}
+
+	name := request.ShareName
+	if name == "" {
+		name = first.file.Name
+	}
+
+	s.shareSeq++
+
+	shareId := fmt.Sprintf("drivetest-share-%d", s.shareSeq)
+	now := time.Now()
+
+	sh := &share{
+		seq: s.shareSeq,
+		link: &models.ShareLink{
+			ShareId:    shareId,
+			ShareName:  name,
+			ShareUrl:   "https://www.aliyundrive.com/s/" + shareId,
+			SharePwd:   request.SharePwd,
+			Expiration: request.Expiration,
+			Status:     models.ShareStatusEnabled,
+			Creator:    s.UserId,
+			DriveId:    request.DriveId,
+			FileIdList: append([]string(nil), request.FileIdList...),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		},
+	}
+
+	s.shares[shareId] = sh
+
+	return http.StatusOK, sh.response()
+}
+
+func (s *Server) listShares(body []byte) (int, interface{}) {
+	var request models.ListSharesRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	var shares []*share
+
+	for _, sh := range s.shares {
+		if sh.link.Creator == request.Creator && (request.IncludeCancelled || !sh.cancelled) {
+			shares = append(shares, sh)
+		}
+	}
+
+	// 按创建顺序倒序
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].seq > shares[j].seq
+	})
+
+	offset, end, nextMarker, ok := pageRange(len(shares), request.Marker, request.Limit)
+	if !ok {
+		return badRequest("invalid marker: " + request.Marker)
+	}
+
+	resp := &models.ListSharesResponse{
+		Items:      []*models.ShareLink{},
+		NextMarker: nextMarker,
+	}
+
+	for _, sh := range shares[offset:end] {
+		resp.Items = append(resp.Items, sh.response())
+	}
+
+	return http.StatusOK, resp
+}
+
+func (s *Server) updateShare(body []byte) (int, interface{}) {
+	var request models.UpdateShareRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	sh, ok := s.shares[request.ShareId]
+	if !ok || sh.cancelled {
+		return shareNotFound(request.ShareId)
+	}
+
+	if request.Expiration != "" {
+		if _, ok := (&models.ShareLink{Expiration: request.Expiration}).ExpireTime(); !ok {
+			return badRequest("invalid expiration: " + request.Expiration)
+		}
+	}
+
+	if request.ShareName != "" {
+		sh.link.ShareName = request.ShareName
+	}
+
+	sh.link.SharePwd = request.SharePwd
+	sh.link.Expiration = request.Expiration
+	sh.link.UpdatedAt = time.Now()
+
+	return http.StatusOK, sh.response()
+}
+
+func (s *Server) cancelShare(body []byte) (int, interface{}) {
+	var request models.CancelShareRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	sh, ok := s.shares[request.ShareId]
+	if !ok || sh.cancelled {
+		return shareNotFound(request.ShareId)
+	}
+
+	sh.cancelled = true
+
+	return http.StatusNoContent, nil
+}
+
+func shareNotFound(shareId string) (int, interface{}) {
+	return newError(http.StatusNotFound, "NotFound.ShareLink",
+		fmt.Sprintf("The resource share_link cannot be found. share_link not exist: %s", shareId))
+}
+
+type shareToken struct {
+	shareId    string
+	expireTime time.Time
+}
+
+// ExpireShareTokens 使所有 share token 失效，用于测试自动刷新 share token
+func (s *Server) ExpireShareTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shareTokens = make(map[string]*shareToken)
+}
+
+// available 分享是否可以访问
+func (sh *share) available() bool {
+	if sh.cancelled {
+		return false
+	}
+
+	t, ok := sh.link.ExpireTime()
+
+	return !ok || t.After(time.Now())
+}
+
+// shareByToken 返回 share token 对应的分享，token 为空、过期或分享不可访问时返回 false
+func (s *Server) shareByToken(token string) (*share, bool) {
+	if token == "" {
+		return nil, false
+	}
+
+	t, ok := s.shareTokens[token]
+	if !ok || t.expireTime.Before(time.Now()) {
+		return nil, false
+	}
+
+	sh, ok := s.shares[t.shareId]
+	if !ok || !sh.available() {
+		return nil, false
+	}
+
+	return sh, true
+}
+
+// sharedEntry 返回分享中的文件，文件须为分享的顶层文件或其子文件
+func (s *Server) sharedEntry(sh *share, fileId string) (*entry, bool) {
+	e, ok := s.lookup(sh.link.DriveId, fileId)
+	if !ok {
+		return nil, false
+	}
+
+	for p := e; p != nil && p.file.FileId != rootFileId; p = s.files[fileKey(p.file.DriveId, p.file.ParentFileId)] {
+		for _, sharedId := range sh.link.FileIdList {
+			if p.file.FileId == sharedId {
+				return e, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// shareFile 返回分享中的文件信息，ParentFileId 对顶层文件替换为 root
+func (s *Server) shareFile(sh *share, e *entry) *models.File {
+	file := copyFile(e.file)
+
+	for _, sharedId := range sh.link.FileIdList {
+		if sharedId == e.file.FileId {
+			file.ParentFileId = rootFileId
+		}
+	}
+
+	return file
+}
+
+func (s *Server) getShareToken(body []byte) (int, interface{}) {
+	var request models.ShareTokenRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	sh, ok := s.shares[request.ShareId]
+	if !ok || !sh.available() {
+		return shareNotFound(request.ShareId)
+	}
+
+	if sh.link.SharePwd != request.SharePwd {
+		return newError(http.StatusBadRequest, "InvalidResource.SharePwd", "The share_pwd is not valid.")
+	}
+
+	s.shareSeq++
+
+	token := fmt.Sprintf("drivetest-share-token-%d", s.shareSeq)
+	expiresIn := 7200
+
+	s.shareTokens[token] = &shareToken{
+		shareId:    sh.link.ShareId,
+		expireTime: time.Now().Add(time.Duration(expiresIn) * time.Second),
+	}
+
+	return http.StatusOK, &models.ShareTokenResponse{
+		ShareToken: token,
+		ExpireTime: s.shareTokens[token].expireTime,
+		ExpiresIn:  expiresIn,
+	}
+}
+
+func (s *Server) shareInfo(body []byte) (int, interface{}) {
+	var request models.ShareInfoRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	sh, ok := s.shares[request.ShareId]
+	if !ok || !sh.available() {
+		return shareNotFound(request.ShareId)
+	}
+
+	resp := &models.ShareInfoResponse{
+		CreatorId:   sh.link.Creator,
+		CreatorName: s.NickName,
+		ShareName:   sh.link.ShareName,
+		Expiration:  sh.link.Expiration,
+		FileInfos:   []*models.ShareFileInfo{},
+		UpdatedAt:   sh.link.UpdatedAt,
+	}
+
+	for _, fileId := range sh.link.FileIdList {
+		if e, ok := s.lookup(sh.link.DriveId, fileId); ok {
+			resp.FileInfos = append(resp.FileInfos, &models.ShareFileInfo{
+				FileId:   fileId,
+				FileName: e.file.Name,
+				Type:     e.file.Type,
+			})
+		}
+	}
+
+	resp.FileCount = len(resp.FileInfos)
+
+	return http.StatusOK, resp
+}
+
+// currentShare 校验请求的 share token 与 shareId 匹配
+func (s *Server) currentShare(shareId string) (*share, bool) {
+	sh := s.requestShare
+	if sh == nil || sh.link.ShareId != shareId {
+		return nil, false
+	}
+
+	return sh, true
+}
+
+func shareTokenInvalid() (int, interface{}) {
+	return newError(http.StatusUnauthorized, models.CodeShareLinkTokenInvalid,
+		"ShareToken is invalid. ErrValidateTokenFailed")
+}
+
+func (s *Server) listByShare(body []byte) (int, interface{}) {
+	var request models.ShareFilesRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	sh, ok := s.currentShare(request.ShareId)
+	if !ok {
+		return shareTokenInvalid()
+	}
+
+	var items []*entry
+
+	if request.ParentFileId == rootFileId {
+		for _, fileId := range sh.link.FileIdList {
+			if e, ok := s.lookup(sh.link.DriveId, fileId); ok {
+				items = append(items, e)
+			}
+		}
+	} else {
+		parent, ok := s.sharedEntry(sh, request.ParentFileId)
+		if !ok || parent.file.Type != models.FileTypeFolder {
+			return notFound(request.ParentFileId)
+		}
+
+		items = s.children(sh.link.DriveId, parent.file.FileId)
+	}
+
+	sortEntries(items, request.OrderBy, request.OrderDirection)
+
+	offset, end, nextMarker, ok := pageRange(len(items), request.Marker, request.Limit)
+	if !ok {
+		return badRequest("invalid marker: " + request.Marker)
+	}
+
+	result := models.Files{
+		Items:      []*models.File{},
+		NextMarker: nextMarker,
+	}
+
+	for _, e := range items[offset:end] {
+		result.Items = append(result.Items, s.shareFile(sh, e))
+	}
+
+	return http.StatusOK, &result
+}
+
+func (s *Server) getByShare(body []byte) (int, interface{}) {
+	var request models.ShareFileRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	sh, ok := s.currentShare(request.ShareId)
+	if !ok {
+		return shareTokenInvalid()
+	}
+
+	e, ok := s.sharedEntry(sh, request.FileId)
+	if !ok {
+		return notFound(request.FileId)
+	}
+
+	return http.StatusOK, s.shareFile(sh, e)
+}
+
+func (s *Server) shareDownloadURL(body []byte) (int, interface{}) {
+	var request models.ShareDownloadURLRequest
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return badRequest(err.Error())
+	}
+
+	sh, ok := s.currentShare(request.ShareId)
+	if !ok {
+		return shareTokenInvalid()
+	}
+
+	e, ok := s.sharedEntry(sh, request.FileId)
+	if !ok || e.file.Type != models.FileTypeFile {
+		return notFound(request.FileId)
+	}
+
+	expireSec := request.ExpireSec
+	if expireSec <= 0 {
+		expireSec = 600
+	}
+
+	url := fmt.Sprintf("%s/download/%s/%s?x-oss-expires=%d", s.URL, sh.link.DriveId, request.FileId, s.downloadUrlSeq)
+
+	return http.StatusOK, &models.ShareDownloadURLResponse{
+		DownloadUrl: url,
+		Url:         url,
+		Expiration:  time.Now().Add(time.Duration(expireSec) * time.Second),
+	}
+}
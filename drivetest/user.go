@@ -0,0 +1,110 @@
+package drivetest
+
+import (
+	"fmt"
+	"github.com/jakeslee/aliyundrive/models"
+	"net/http"
+	"time"
+)
+
+// User 模拟服务中默认用户以外的用户，只有一个默认 Drive，用于测试跨账号操作
+type User struct {
+	RefreshToken string
+	UserId       string
+	DriveId      string
+
+	accessToken string
+}
+
+// AddUser 添加用户，RefreshToken 和 DriveId 根据 userId 生成
+func (s *Server) AddUser(userId string) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := &User{
+		RefreshToken: "drivetest-refresh-token-" + userId,
+		UserId:       userId,
+		DriveId:      fmt.Sprintf("%d", 20001+len(s.users)),
+	}
+
+	s.users = append(s.users, u)
+
+	s.files[fileKey(u.DriveId, rootFileId)] = &entry{
+		file: &models.File{
+			DriveId:   u.DriveId,
+			DomainId:  DefaultDomainId,
+			FileId:    rootFileId,
+			Name:      rootFileId,
+			Type:      models.FileTypeFolder,
+			Status:    models.FileStatusAvailable,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+
+	return u
+}
+
+// authorize 校验 Authorization 头，通过时记录当前请求的用户，默认用户为 nil
+func (s *Server) authorize(request *http.Request) bool {
+	authorization := request.Header.Get("Authorization")
+
+	if s.accessToken != "" && authorization == "Bearer "+s.accessToken {
+		s.requestUser, s.requestToken = nil, s.accessToken
+		return true
+	}
+
+	for _, u := range s.users {
+		if u.accessToken != "" && authorization == "Bearer "+u.accessToken {
+			s.requestUser, s.requestToken = u, u.accessToken
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) userByRefreshToken(refreshToken string) (*User, bool) {
+	for _, u := range s.users {
+		if u.RefreshToken == refreshToken {
+			return u, true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *Server) userToken(u *User) (int, interface{}) {
+	s.tokenSeq++
+	u.accessToken = fmt.Sprintf("drivetest-access-token-%s-%d", u.UserId, s.tokenSeq)
+
+	return http.StatusOK, map[string]interface{}{
+		"access_token":     u.accessToken,
+		"refresh_token":    u.RefreshToken,
+		"expires_in":       7200,
+		"token_type":       "Bearer",
+		"user_id":          u.UserId,
+		"user_name":        u.UserId,
+		"nick_name":        u.UserId,
+		"default_drive_id": u.DriveId,
+		"domain_id":        DefaultDomainId,
+		"status":           "enabled",
+		"role":             "user",
+	}
+}
+
+func (s *Server) userDrives(u *User) (int, interface{}) {
+	return http.StatusOK, &models.ListDrivesResponse{
+		Items: []*models.Drive{
+			{
+				DriveId:   u.DriveId,
+				DriveName: "Default",
+				DriveType: "normal",
+				Category:  "default",
+				Owner:     u.UserId,
+				OwnerType: "user",
+				Status:    "enabled",
+			},
+		},
+	}
+}
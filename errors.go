@@ -0,0 +1,14 @@
+package aliyundrive
+
+import "github.com/jakeslee/aliyundrive/http"
+
+// 常见服务端错误，接口返回的 *http.AliyunDriveError 可以通过 errors.Is 判断，
+// 通过 errors.As 获取错误码、HTTP 状态码、请求 ID 以及是否可以重试
+var (
+	ErrNotFound       = http.ErrNotFound
+	ErrAlreadyExists  = http.ErrAlreadyExists
+	ErrQuotaExhausted = http.ErrQuotaExhausted
+	ErrInvalidToken   = http.ErrInvalidToken
+	ErrThrottled      = http.ErrThrottled
+	ErrForbidden      = http.ErrForbidden
+)
@@ -0,0 +1,48 @@
+package aliyundrive
+
+import (
+	"errors"
+	"github.com/jakeslee/aliyundrive/http"
+	"testing"
+)
+
+func TestAliyunDrive_Errors(t *testing.T) {
+	drive, cred, server := newTestClientWithOptions(t, &Options{MaxRetries: -1})
+
+	_, err := drive.GetFile(cred, "not-exist")
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+
+	var driveErr *http.AliyunDriveError
+	if !errors.As(err, &driveErr) || driveErr.StatusCode != 404 || driveErr.RequestId == "" || driveErr.Retryable {
+		t.Errorf("unexpected error detail %+v", driveErr)
+	}
+
+	a := server.AddFile(DefaultRootFileId, "a.txt", []byte("a"))
+	server.AddFile(DefaultRootFileId, "b.txt", []byte("b"))
+
+	renames, err := drive.BatchRename(cred, []*BatchRenameItem{{FileId: a.FileId, Name: "b.txt"}})
+	if err != nil {
+		t.Fatalf("batch rename error %v", err)
+	}
+
+	if err := renames[0].Err(); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("expect ErrAlreadyExists, got %v", err)
+	}
+
+	drive.EvictCacheWithPrefix("")
+	server.Throttle(1, 0)
+
+	_, err = drive.GetFile(cred, a.FileId)
+	if !errors.Is(err, ErrThrottled) || !errors.As(err, &driveErr) || !driveErr.Retryable {
+		t.Errorf("expect retryable ErrThrottled, got %v", err)
+	}
+
+	server.ExpireAccessToken()
+	cred.RefreshToken = "invalid"
+
+	if _, err := drive.GetFile(cred, DefaultRootFileId); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expect ErrInvalidToken, got %v", err)
+	}
+}
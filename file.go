@@ -1,13 +1,14 @@
 package aliyundrive
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"github.com/jakeslee/aliyundrive/http"
 	"github.com/jakeslee/aliyundrive/models"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/time/rate"
 	"io"
 	"math/big"
@@ -16,16 +17,21 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	// ThunkSizeDefault 默认 10MB 大小
 	ThunkSizeDefault = 1024 * 1024 * 10
-	timeLayout       = "2006-01-02T15:04:05.000Z"
+	// partSizeAlign 自动计算的分片大小按 1MB 对齐
+	partSizeAlign   = 1024 * 1024
+	timeLayout      = "2006-01-02T15:04:05.000Z"
+	pathCachePrefix = "path:"
 )
 
 type FolderFilesOptions struct {
+	DriveId        string // 为空时使用 credential.DefaultDriveId
 	FolderFileId   string
 	OrderBy        string
 	OrderDirection string
@@ -34,7 +40,13 @@ type FolderFilesOptions struct {
 
 // GetFolderFiles 获取指定目录下的文件列表
 func (d *AliyunDrive) GetFolderFiles(credential *Credential, options *FolderFilesOptions) (*models.FolderFilesResponse, error) {
-	cacheKey := fmt.Sprintf("%s:%s", options.FolderFileId, options.Marker)
+	return d.GetFolderFilesWithContext(context.Background(), credential, options)
+}
+
+// GetFolderFilesWithContext 同 GetFolderFiles，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetFolderFilesWithContext(ctx context.Context, credential *Credential, options *FolderFilesOptions) (*models.FolderFilesResponse, error) {
+	driveId := d.driveId(credential, options.DriveId)
+	cacheKey := fmt.Sprintf("%s:%s", fileCacheKey(driveId, options.FolderFileId), options.Marker)
 
 	var resp models.FolderFilesResponse
 
@@ -44,13 +56,13 @@ func (d *AliyunDrive) GetFolderFiles(credential *Credential, options *FolderFile
 
 	request := models.NewFolderFilesRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = driveId
 	request.ParentFileId = options.FolderFileId
 	request.OrderBy = options.OrderBy
 	request.OrderDirection = options.OrderDirection
 	request.Marker = options.Marker
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	if err == nil {
 		_ = d.cache.Set(cacheKey, &resp)
@@ -64,18 +76,33 @@ func (d *AliyunDrive) GetFolderFiles(credential *Credential, options *FolderFile
 
 // GetByPath 通过 Path 取得文件信息，不存在则错误
 func (d *AliyunDrive) GetByPath(credential *Credential, fullPath string) (*models.FileResponse, error) {
+	return d.GetByPathWithContext(context.Background(), credential, fullPath)
+}
+
+// GetByPathWithContext 同 GetByPath，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetByPathWithContext(ctx context.Context, credential *Credential, fullPath string) (*models.FileResponse, error) {
+	return d.GetByPathInDriveWithContext(ctx, credential, "", fullPath)
+}
+
+// GetByPathInDrive 同 GetByPath，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) GetByPathInDrive(credential *Credential, driveId, fullPath string) (*models.FileResponse, error) {
+	return d.GetByPathInDriveWithContext(context.Background(), credential, driveId, fullPath)
+}
+
+// GetByPathInDriveWithContext 同 GetByPathInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetByPathInDriveWithContext(ctx context.Context, credential *Credential, driveId, fullPath string) (*models.FileResponse, error) {
 	fullPath = PrefixSlash(filepath.Clean(fullPath))
 
 	request := models.NewGetFileByPathRequest()
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 	request.FilePath = fullPath
 
 	var resp models.FileResponse
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	if err == nil {
-		_ = d.cache.Set(resp.FileId, &resp)
+		_ = d.cache.Set(fileCacheKey(request.DriveId, resp.FileId), &resp)
 	}
 
 	return &resp, err
@@ -88,13 +115,28 @@ var ErrPartialFoundPath = errors.New("partial found: only found partial parent")
 // 当查找到路径前一部分时，返回 fileId, prefix, ErrPartialFoundPath
 // 当全部找到时，返回 fileId, fullpath, nil
 func (d *AliyunDrive) ResolvePathToFileId(credential *Credential, fullpath string) (string, string, error) {
+	return d.ResolvePathToFileIdWithContext(context.Background(), credential, fullpath)
+}
+
+// ResolvePathToFileIdWithContext 同 ResolvePathToFileId，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ResolvePathToFileIdWithContext(ctx context.Context, credential *Credential, fullpath string) (string, string, error) {
+	return d.ResolvePathToFileIdInDriveWithContext(ctx, credential, "", fullpath)
+}
+
+// ResolvePathToFileIdInDrive 同 ResolvePathToFileId，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) ResolvePathToFileIdInDrive(credential *Credential, driveId, fullpath string) (string, string, error) {
+	return d.ResolvePathToFileIdInDriveWithContext(context.Background(), credential, driveId, fullpath)
+}
+
+// ResolvePathToFileIdInDriveWithContext 同 ResolvePathToFileIdInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ResolvePathToFileIdInDriveWithContext(ctx context.Context, credential *Credential, driveId, fullpath string) (string, string, error) {
 	path := PrefixSlash(filepath.Clean(fullpath))
 
 	foundPath := "/"
 
 	if path == "/" {
 		go func() {
-			_, _ = d.GetFile(credential, DefaultRootFileId)
+			_, _ = d.GetFileInDriveWithContext(ctx, credential, driveId, DefaultRootFileId)
 		}()
 		return DefaultRootFileId, foundPath, nil
 	}
@@ -108,7 +150,8 @@ func (d *AliyunDrive) ResolvePathToFileId(credential *Credential, fullpath strin
 		marker := ""
 
 		for !matched {
-			folderFiles, err := d.GetFolderFiles(credential, &FolderFilesOptions{
+			folderFiles, err := d.GetFolderFilesWithContext(ctx, credential, &FolderFilesOptions{
+				DriveId:        driveId,
 				OrderBy:        "updated_at",
 				OrderDirection: models.OrderDirectionTypeDescend,
 				FolderFileId:   fileId,
@@ -146,7 +189,7 @@ func (d *AliyunDrive) ResolvePathToFileId(credential *Credential, fullpath strin
 // cacheFiles 缓存 FileId 对应的 File 信息
 func (d *AliyunDrive) cacheFiles(files []*models.File) {
 	for _, file := range files {
-		_ = d.cache.Set(file.FileId, &models.FileResponse{
+		_ = d.cache.Set(fileCacheKey(file.DriveId, file.FileId), &models.FileResponse{
 			File: file,
 		})
 	}
@@ -154,52 +197,166 @@ func (d *AliyunDrive) cacheFiles(files []*models.File) {
 
 // GetFile 获取文件信息
 func (d *AliyunDrive) GetFile(credential *Credential, fileId string) (*models.FileResponse, error) {
-	if v, err := d.cache.Get(fileId); err == nil {
+	return d.GetFileWithContext(context.Background(), credential, fileId)
+}
+
+// GetFileWithContext 同 GetFile，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetFileWithContext(ctx context.Context, credential *Credential, fileId string) (*models.FileResponse, error) {
+	return d.GetFileInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// GetFileInDrive 同 GetFile，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) GetFileInDrive(credential *Credential, driveId, fileId string) (*models.FileResponse, error) {
+	return d.GetFileInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// GetFileInDriveWithContext 同 GetFileInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetFileInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) (*models.FileResponse, error) {
+	driveId = d.driveId(credential, driveId)
+	key := fileCacheKey(driveId, fileId)
+
+	if v, err := d.cache.Get(key); err == nil {
 		return v.(*models.FileResponse), nil
 	}
 
 	request := models.NewFileRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = driveId
 	request.FileId = fileId
 
 	var resp models.FileResponse
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	if err == nil {
-		_ = d.cache.Set(fileId, &resp)
+		_ = d.cache.Set(key, &resp)
 	}
 
 	return &resp, err
 }
 
+// GetPath 获取文件的路径信息，Items 从文件自身开始依次为上级目录，不包含根目录
+func (d *AliyunDrive) GetPath(credential *Credential, fileId string) (*models.GetPathResponse, error) {
+	return d.GetPathWithContext(context.Background(), credential, fileId)
+}
+
+// GetPathWithContext 同 GetPath，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetPathWithContext(ctx context.Context, credential *Credential, fileId string) (*models.GetPathResponse, error) {
+	return d.GetPathInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// GetPathInDrive 同 GetPath，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) GetPathInDrive(credential *Credential, driveId, fileId string) (*models.GetPathResponse, error) {
+	return d.GetPathInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// GetPathInDriveWithContext 同 GetPathInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetPathInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) (*models.GetPathResponse, error) {
+	driveId = d.driveId(credential, driveId)
+	key := pathCachePrefix + fileCacheKey(driveId, fileId)
+
+	if cached, err := d.cache.Get(key); err == nil {
+		return cached.(*models.GetPathResponse), nil
+	}
+
+	request := models.NewGetPathRequest()
+
+	request.DriveId = driveId
+	request.FileId = fileId
+
+	var resp models.GetPathResponse
+
+	err := d.send(ctx, credential, request, &resp)
+
+	if err == nil {
+		_ = d.cache.Set(key, &resp)
+
+		go d.cacheFiles(resp.Items)
+	}
+
+	return &resp, err
+}
+
+// GetFullPath 获取文件的绝对路径，如 /a/b/c.txt，根目录返回 /
+func (d *AliyunDrive) GetFullPath(credential *Credential, fileId string) (string, error) {
+	return d.GetFullPathWithContext(context.Background(), credential, fileId)
+}
+
+// GetFullPathWithContext 同 GetFullPath，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetFullPathWithContext(ctx context.Context, credential *Credential, fileId string) (string, error) {
+	return d.GetFullPathInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// GetFullPathInDrive 同 GetFullPath，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) GetFullPathInDrive(credential *Credential, driveId, fileId string) (string, error) {
+	return d.GetFullPathInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// GetFullPathInDriveWithContext 同 GetFullPathInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetFullPathInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) (string, error) {
+	if fileId == DefaultRootFileId {
+		return "/", nil
+	}
+
+	resp, err := d.GetPathInDriveWithContext(ctx, credential, driveId, fileId)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, len(resp.Items))
+
+	for i, item := range resp.Items {
+		names[len(resp.Items)-1-i] = item.Name
+	}
+
+	return "/" + strings.Join(names, "/"), nil
+}
+
+// evictPathCache 失效所有路径缓存，目录重命名或移动会影响其下所有文件的路径
+func (d *AliyunDrive) evictPathCache() {
+	d.EvictCacheWithPrefix(pathCachePrefix)
+}
+
 // GetDownloadURL 获取下载路经
 // https://www.aliyundrive.com 获取的 RefreshToken 得到的 URL 需要带 Referrer 下载
 // 移动端 Web 或手机端获取的 RefreshToken 得到的 URL可以直链下载
 func (d *AliyunDrive) GetDownloadURL(credential *Credential, fileId string) (*models.DownloadURLResponse, error) {
+	return d.GetDownloadURLWithContext(context.Background(), credential, fileId)
+}
+
+// GetDownloadURLWithContext 同 GetDownloadURL，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetDownloadURLWithContext(ctx context.Context, credential *Credential, fileId string) (*models.DownloadURLResponse, error) {
+	return d.GetDownloadURLInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// GetDownloadURLInDrive 同 GetDownloadURL，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) GetDownloadURLInDrive(credential *Credential, driveId, fileId string) (*models.DownloadURLResponse, error) {
+	return d.GetDownloadURLInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// GetDownloadURLInDriveWithContext 同 GetDownloadURLInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetDownloadURLInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) (*models.DownloadURLResponse, error) {
 	var resp models.DownloadURLResponse
 
-	key := fileId + ":url"
+	key := downloadURLCacheKey(d.driveId(credential, driveId), fileId)
 
 	if cached, err := d.cache.Get(key); err == nil {
 		response := cached.(*models.DownloadURLResponse)
 
 		urlExp, err := time.Parse(timeLayout, response.Expiration)
 
-		if err == nil {
-			if time.Now().Sub(urlExp) > time.Hour {
-				return response, nil
-			}
+		// 缓存的地址即将过期时重新获取
+		if err == nil && time.Until(urlExp) > downloadURLRefreshAhead {
+			return response, nil
 		}
 	}
 
 	request := models.NewDownloadURLRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 	request.FileId = fileId
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	if err == nil {
 		_ = d.cache.Set(key, &resp)
@@ -208,17 +365,79 @@ func (d *AliyunDrive) GetDownloadURL(credential *Credential, fileId string) (*mo
 	return &resp, err
 }
 
+// downloadURLRefreshAhead 下载地址在过期前提前重新获取的时间
+const downloadURLRefreshAhead = 5 * time.Minute
+
+func downloadURLCacheKey(driveId, fileId string) string {
+	return fileCacheKey(driveId, fileId) + ":url"
+}
+
+// evictDownloadURL 下载地址失效时删除缓存，下次重新获取
+func (d *AliyunDrive) evictDownloadURL(credential *Credential, driveId, fileId string) {
+	d.cache.Delete(downloadURLCacheKey(d.driveId(credential, driveId), fileId))
+}
+
 // Download 下载文件
 func (d *AliyunDrive) Download(credential *Credential, fileId, requestRange string) (*http2.Response, error) {
-	urlResponse, err := d.GetDownloadURL(credential, fileId)
+	return d.DownloadWithContext(context.Background(), credential, fileId, requestRange)
+}
+
+// DownloadWithContext 同 Download，通过 ctx 控制取消和超时
+func (d *AliyunDrive) DownloadWithContext(ctx context.Context, credential *Credential, fileId, requestRange string) (*http2.Response, error) {
+	return d.DownloadInDriveWithContext(ctx, credential, "", fileId, requestRange)
+}
+
+// DownloadInDrive 同 Download，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) DownloadInDrive(credential *Credential, driveId, fileId, requestRange string) (*http2.Response, error) {
+	return d.DownloadInDriveWithContext(context.Background(), credential, driveId, fileId, requestRange)
+}
+
+// DownloadInDriveWithContext 同 DownloadInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) DownloadInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId, requestRange string) (*http2.Response, error) {
+	res, err := d.download(ctx, credential, driveId, fileId, requestRange)
+	if err != nil {
+		return nil, err
+	}
+
+	// 缓存的下载地址已过期时重新获取并重试一次
+	if res.StatusCode == http2.StatusForbidden {
+		_ = res.Body.Close()
+
+		d.evictDownloadURL(credential, driveId, fileId)
+
+		res, err = d.download(ctx, credential, driveId, fileId, requestRange)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res.Body = &rateLimitedReadCloser{
+		RateLimiterReader: RateLimiterReader{
+			Reader:   res.Body,
+			limiters: d.downloadRateLimiters(credential),
+			ctx:      ctx,
+		},
+		Closer: res.Body,
+	}
+
+	return res, nil
+}
 
+func (d *AliyunDrive) download(ctx context.Context, credential *Credential, driveId, fileId, requestRange string) (*http2.Response, error) {
+	urlResponse, err := d.GetDownloadURLInDriveWithContext(ctx, credential, driveId, fileId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	downloadUrl, err := rewriteURL(*urlResponse.Url, d.downloadEndpoint)
 	if err != nil {
 		return nil, err
 	}
 
-	logrus.Debugf("download file %s, url: %s", fileId, *urlResponse.Url)
+	d.logger.Debug("download file", "file_id", fileId, "url", downloadUrl, "range", requestRange)
 
-	request, err := http2.NewRequest(http2.MethodGet, *urlResponse.Url, nil)
+	request, err := http2.NewRequestWithContext(ctx, http2.MethodGet, downloadUrl, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -243,40 +462,71 @@ func (d *AliyunDrive) Download(credential *Credential, fileId, requestRange stri
 
 	res, err := d.rawClient.Do(request)
 
-	logrus.Debugf("request %s finished", fileId)
-
 	if err != nil {
+		d.logger.Debug("download file request error", "file_id", fileId, "error", err)
 		return nil, err
 	}
 
+	d.logger.Debug("download file response", "file_id", fileId, "status", res.StatusCode)
+
 	return res, nil
 }
 
 // Search 查找文件
 func (d *AliyunDrive) Search(credential *Credential, keyword, marker string) (*models.SearchResponse, error) {
+	return d.SearchWithContext(context.Background(), credential, keyword, marker)
+}
+
+// SearchWithContext 同 Search，通过 ctx 控制取消和超时
+func (d *AliyunDrive) SearchWithContext(ctx context.Context, credential *Credential, keyword, marker string) (*models.SearchResponse, error) {
+	return d.SearchInDriveWithContext(ctx, credential, "", keyword, marker)
+}
+
+// SearchInDrive 同 Search，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) SearchInDrive(credential *Credential, driveId, keyword, marker string) (*models.SearchResponse, error) {
+	return d.SearchInDriveWithContext(context.Background(), credential, driveId, keyword, marker)
+}
+
+// SearchInDriveWithContext 同 SearchInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) SearchInDriveWithContext(ctx context.Context, credential *Credential, driveId, keyword, marker string) (*models.SearchResponse, error) {
 	request := models.NewSearchRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 	request.Query = fmt.Sprintf("name match '%s'", keyword)
 	request.Marker = marker
 
 	var resp models.SearchResponse
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	return &resp, err
 }
 
 // SearchNameInFolder 在目录下查找文件名
 func (d *AliyunDrive) SearchNameInFolder(credential *Credential, name, parentFileId string) (*models.SearchResponse, error) {
+	return d.SearchNameInFolderWithContext(context.Background(), credential, name, parentFileId)
+}
+
+// SearchNameInFolderWithContext 同 SearchNameInFolder，通过 ctx 控制取消和超时
+func (d *AliyunDrive) SearchNameInFolderWithContext(ctx context.Context, credential *Credential, name, parentFileId string) (*models.SearchResponse, error) {
+	return d.SearchNameInFolderInDriveWithContext(ctx, credential, "", name, parentFileId)
+}
+
+// SearchNameInFolderInDrive 同 SearchNameInFolder，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) SearchNameInFolderInDrive(credential *Credential, driveId, name, parentFileId string) (*models.SearchResponse, error) {
+	return d.SearchNameInFolderInDriveWithContext(context.Background(), credential, driveId, name, parentFileId)
+}
+
+// SearchNameInFolderInDriveWithContext 同 SearchNameInFolderInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) SearchNameInFolderInDriveWithContext(ctx context.Context, credential *Credential, driveId, name, parentFileId string) (*models.SearchResponse, error) {
 	request := models.NewSearchRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 	request.Query = fmt.Sprintf("parent_file_id = \"%s\" and (name = \"%s\")", parentFileId, name)
 
 	var resp models.SearchResponse
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	return &resp, err
 }
@@ -285,33 +535,42 @@ func (d *AliyunDrive) SearchNameInFolder(credential *Credential, name, parentFil
 // proof code 是文件内容的部分片段，主要用于实现秒传
 // 算法：使用 AccessToken MD5 值的前 16 位 HEX 值转换为十进制数并对文件大小取模，
 // 结果作为 proof code 的获取起始位置，取文件内容 8 位 byte 并用 Base64 编码
-func (d *AliyunDrive) ComputeProofCodeV1(credential *Credential, file *os.File, size int64) (string, error) {
-	hashed := ToMD5(credential.AccessToken)[0:16]
-	hashedInt, _ := new(big.Int).SetString(hashed, 16)
-
-	start := hashedInt.Mod(hashedInt, big.NewInt(size)).Int64()
-	end := Min(start+8, size)
+func (d *AliyunDrive) ComputeProofCodeV1(credential *Credential, file io.ReaderAt, size int64) (string, error) {
+	start, end := proofRangeV1(credential, size)
 
 	n := end - start
 
 	proof := make([]byte, n)
 
-	_, err := file.Seek(start, 0)
-	if err != nil {
-		return "", err
+	read, err := file.ReadAt(proof, start)
+	if err == io.EOF && int64(read) == n {
+		err = nil
 	}
 
-	read, err := file.Read(proof)
 	if err != nil || int64(read) != n {
-		return "", errors.New(fmt.Sprintf("read proof_code, read: %d, n: %d error %s", read, n, err))
+		return "", fmt.Errorf("read proof_code, read: %d, n: %d error %w", read, n, err)
 	}
 
 	return base64.StdEncoding.EncodeToString(proof), nil
 }
 
+// proofRangeV1 proof code v1 在文件中的位置 [start, end)
+func proofRangeV1(credential *Credential, size int64) (start, end int64) {
+	if size <= 0 {
+		return 0, 0
+	}
+
+	hashed := ToMD5(credential.AccessToken)[0:16]
+	hashedInt, _ := new(big.Int).SetString(hashed, 16)
+
+	start = hashedInt.Mod(hashedInt, big.NewInt(size)).Int64()
+
+	return start, Min(start+8, size)
+}
+
 // ComputePreHash 计算文件 PreHash，只计算前 1KB 的 SHA1
 func (d *AliyunDrive) ComputePreHash(content io.Reader) (string, error) {
-	reader := io.LimitReader(content, 1024)
+	reader := io.LimitReader(content, preHashSize)
 
 	preHash, err := ToSHA1WithReader(reader)
 	if err != nil {
@@ -322,6 +581,7 @@ func (d *AliyunDrive) ComputePreHash(content io.Reader) (string, error) {
 }
 
 type CreateWithFoldersOptions struct {
+	DriveId       string // 为空时使用 credential.DefaultDriveId
 	Name          string
 	ParentFileId  string // 父路径
 	Size          int64
@@ -329,17 +589,35 @@ type CreateWithFoldersOptions struct {
 	PreHash       string
 	ContentHash   string
 	ProofCode     string
+	PartSize      int64 // 分片大小，为 0 时根据 Size 自动计算
+}
+
+// AdaptivePartSize 根据文件大小计算分片大小，不超过 models.MaxPartCount 个分片时使用 ThunkSizeDefault，
+// 否则平均分成 models.MaxPartCount 个分片并按 1MB 对齐
+func AdaptivePartSize(size int64) int64 {
+	if size <= ThunkSizeDefault*models.MaxPartCount {
+		return ThunkSizeDefault
+	}
+
+	partSize := (size + models.MaxPartCount - 1) / models.MaxPartCount
+
+	return (partSize + partSizeAlign - 1) / partSizeAlign * partSizeAlign
 }
 
 // CreateWithFolders 在目录下创建文件，如果非秒传，接下来需要分片上传
 // 响应内容中，如果需要上传，则从 PartInfoList 中获取分片上传地址信息
 func (d *AliyunDrive) CreateWithFolders(credential *Credential, options *CreateWithFoldersOptions) (http.Response, error) {
+	return d.CreateWithFoldersWithContext(context.Background(), credential, options)
+}
+
+// CreateWithFoldersWithContext 同 CreateWithFolders，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CreateWithFoldersWithContext(ctx context.Context, credential *Credential, options *CreateWithFoldersOptions) (http.Response, error) {
 	var request *models.CreateWithFolders
 	var r http.Request
 	var resp http.Response
 
-	// 如果没提供 proof code 则使用 PreHash 创建文件
-	if options.ProofCode == "" {
+	// 如果没提供 proof code 和内容 HASH 则使用 PreHash 创建文件
+	if options.ProofCode == "" && options.ContentHash == "" {
 		preHashRequest := models.NewCreateWithFoldersPreHashRequest()
 
 		preHashRequest.PreHash = options.PreHash
@@ -365,33 +643,81 @@ func (d *AliyunDrive) CreateWithFolders(credential *Credential, options *CreateW
 
 	request.Name = options.Name
 	request.Size = options.Size
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, options.DriveId)
 	request.ParentFileId = options.ParentFileId
 
 	var err error
 
-	request.PartInfoList, err = models.NewPartInfoList(options.Size, ThunkSizeDefault)
+	partSize := options.PartSize
+	if partSize == 0 {
+		partSize = AdaptivePartSize(options.Size)
+	}
+
+	request.PartInfoList, err = models.NewPartInfoList(options.Size, partSize)
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = d.send(credential, r, resp)
+	err = d.send(ctx, credential, r, resp)
+
+	switch v := resp.(type) {
+	case *models.CreateWithFoldersPreHashResponse:
+		mergePartInfoList(v.PartInfoList, request.PartInfoList)
+	case *models.CreateWithFoldersWithProofResponse:
+		mergePartInfoList(v.PartInfoList, request.PartInfoList)
+	}
 
 	return resp, err
 }
 
+// mergePartInfoList 服务端返回的分片信息不包含偏移量，按分片序号从本地分片信息补全
+func mergePartInfoList(parts []*models.PartInfo, local []*models.PartInfo) {
+	byNumber := make(map[int]*models.PartInfo)
+
+	for _, info := range local {
+		byNumber[info.PartNumber] = info
+	}
+
+	for _, info := range parts {
+		if l, ok := byNumber[info.PartNumber]; ok {
+			info.Id = l.Id
+			info.StartOffset = l.StartOffset
+			info.EndOffset = l.EndOffset
+
+			if info.PartSize == 0 {
+				info.PartSize = l.PartSize
+			}
+		}
+	}
+}
+
 // CompleteUpload 完成分片上传后，通过此接口结束上传（合并分片）
 func (d *AliyunDrive) CompleteUpload(credential *Credential, fileId, uploadId string) (*models.CompleteFileUploadResponse, error) {
+	return d.CompleteUploadWithContext(context.Background(), credential, fileId, uploadId)
+}
+
+// CompleteUploadWithContext 同 CompleteUpload，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CompleteUploadWithContext(ctx context.Context, credential *Credential, fileId, uploadId string) (*models.CompleteFileUploadResponse, error) {
+	return d.CompleteUploadInDriveWithContext(ctx, credential, "", fileId, uploadId)
+}
+
+// CompleteUploadInDrive 同 CompleteUpload，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) CompleteUploadInDrive(credential *Credential, driveId, fileId, uploadId string) (*models.CompleteFileUploadResponse, error) {
+	return d.CompleteUploadInDriveWithContext(context.Background(), credential, driveId, fileId, uploadId)
+}
+
+// CompleteUploadInDriveWithContext 同 CompleteUploadInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CompleteUploadInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId, uploadId string) (*models.CompleteFileUploadResponse, error) {
 	request := models.NewCompleteFileUploadRequest()
 
 	request.UploadId = uploadId
 	request.FileId = fileId
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 
 	var resp models.CompleteFileUploadResponse
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	return &resp, err
 }
@@ -399,6 +725,11 @@ func (d *AliyunDrive) CompleteUpload(credential *Credential, fileId, uploadId st
 // PartUpload 分片数据上传
 // 因服务端使用流式计算 SHA1 值，单个文件的分片需要串行上传，不支持多个分片平行上传
 func (d *AliyunDrive) PartUpload(credential *Credential, uploadUrl string, reader io.Reader, callback ProgressCallback) error {
+	return d.PartUploadWithContext(context.Background(), credential, uploadUrl, reader, callback)
+}
+
+// PartUploadWithContext 同 PartUpload，通过 ctx 控制取消和超时
+func (d *AliyunDrive) PartUploadWithContext(ctx context.Context, credential *Credential, uploadUrl string, reader io.Reader, callback ProgressCallback) error {
 	var p io.Reader
 
 	p = &progressReader{
@@ -406,14 +737,18 @@ func (d *AliyunDrive) PartUpload(credential *Credential, uploadUrl string, reade
 		callback,
 	}
 
-	if d.uploadLimitEnable {
-		p = &RateLimiterReader{
-			limiter: d.uploadRateLimiter,
-			Reader:  p,
-		}
+	p = &RateLimiterReader{
+		limiters: []*rate.Limiter{d.uploadRateLimiter},
+		ctx:      ctx,
+		Reader:   p,
 	}
 
-	request, err := http2.NewRequest("PUT", uploadUrl, p)
+	uploadUrl, err := rewriteURL(uploadUrl, d.uploadEndpoint)
+	if err != nil {
+		return err
+	}
+
+	request, err := http2.NewRequestWithContext(ctx, "PUT", uploadUrl, p)
 	if err != nil {
 		return err
 	}
@@ -428,45 +763,175 @@ func (d *AliyunDrive) PartUpload(credential *Credential, uploadUrl string, reade
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
-			logrus.Errorf("close file error %s", err)
+			d.logger.Error("close part upload response error", "error", err)
 		}
 	}(response.Body)
 
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return newPartUploadError(response)
+	}
+
 	return nil
 }
 
+// PartUploadError 分片上传失败，Code 和 Message 来自 OSS 返回的错误信息
+type PartUploadError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *PartUploadError) Error() string {
+	return fmt.Sprintf("[PartUploadError] StatusCode=%d, Code=%s, Message=%s", e.StatusCode, e.Code, e.Message)
+}
+
+// Expired 上传地址是否已过期，过期后需要通过 GetUploadUrl 重新获取
+func (e *PartUploadError) Expired() bool {
+	return e.StatusCode == http2.StatusForbidden
+}
+
+// Is 支持 errors.Is 判断 ErrForbidden 和 ErrNotFound
+func (e *PartUploadError) Is(target error) bool {
+	switch e.StatusCode {
+	case http2.StatusForbidden:
+		return target == ErrForbidden
+	case http2.StatusNotFound:
+		return target == ErrNotFound
+	}
+
+	return false
+}
+
+func newPartUploadError(response *http2.Response) error {
+	partErr := &PartUploadError{
+		StatusCode: response.StatusCode,
+	}
+
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+
+	if xml.Unmarshal(data, &body) == nil {
+		partErr.Code, partErr.Message = body.Code, body.Message
+	} else {
+		partErr.Message = string(data)
+	}
+
+	return partErr
+}
+
 type UploadFileRapidOptions struct {
 	UploadFileOptions
 
-	File        *os.File
+	// File 上传的数据，大小为 Size，可以是文件、内存数据或 io.NewSectionReader 截取的片段。
+	// 为空时使用 Reader，Reader 不支持 io.ReaderAt 时先缓存到 TempDir 的临时文件中
+	File        io.ReaderAt
 	ContentHash string
+	Crc64       bool   // 同时计算 CRC64，上传完成后与服务端的 CRC64 校验
+	TempDir     string // 缓存 Reader 的临时目录，为空时使用系统临时目录
+}
+
+// rapidUploadSource 返回秒传的数据源和大小，cleanup 用于删除缓存的临时文件
+func (d *AliyunDrive) rapidUploadSource(options *UploadFileRapidOptions) (source io.ReaderAt, size int64, cleanup func(), err error) {
+	cleanup = func() {}
+
+	if options.File != nil {
+		return options.File, options.Size, cleanup, nil
+	}
+
+	if options.Reader == nil {
+		return nil, 0, cleanup, errors.New("upload rapid requires File or Reader")
+	}
+
+	if readerAt, ok := options.Reader.(io.ReaderAt); ok {
+		return readerAt, options.Size, cleanup, nil
+	}
+
+	temp, err := os.CreateTemp(options.TempDir, "aliyundrive-upload-*")
+	if err != nil {
+		return nil, 0, cleanup, err
+	}
+
+	cleanup = func() {
+		_ = temp.Close()
+
+		if err := os.Remove(temp.Name()); err != nil {
+			d.logger.Warn("remove temp file error", "path", temp.Name(), "error", err)
+		}
+	}
+
+	size, err = io.Copy(temp, options.Reader)
+	if err != nil {
+		cleanup()
+		return nil, 0, func() {}, err
+	}
+
+	if options.Size > 0 && size != options.Size {
+		cleanup()
+		return nil, 0, func() {}, fmt.Errorf("read %d bytes from reader, expect %d", size, options.Size)
+	}
+
+	return temp, size, cleanup, nil
 }
 
+// ErrChecksumMismatch 上传完成后本地计算的 HASH 与服务端不一致
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 // UploadFileRapid 上传文件（秒传）
 // 当文件较大（如1GB以上）时，计算整个文件的 sha1 将花费较大的资源。先执行预秒传匹配到可能的数据才执行秒传。
+// 文件内容只读取一次：预秒传未匹配时边上传边计算 HASH 并在完成后校验，匹配时一次读取同时计算 SHA1 和 proof code，
+// 仅在秒传失败（预秒传 HASH 碰撞）时需要再次读取文件上传
 func (d *AliyunDrive) UploadFileRapid(credential *Credential, options *UploadFileRapidOptions) (file *models.File, rapid bool, err error) {
-	// 重置文件读取位置
-	_, err = options.File.Seek(0, 0)
+	return d.UploadFileRapidWithContext(context.Background(), credential, options)
+}
+
+// UploadFileRapidWithContext 同 UploadFileRapid，通过 ctx 控制取消和超时
+func (d *AliyunDrive) UploadFileRapidWithContext(ctx context.Context, credential *Credential, options *UploadFileRapidOptions) (file *models.File, rapid bool, err error) {
+	source, size, cleanup, err := d.rapidUploadSource(options)
 	if err != nil {
 		return nil, false, err
 	}
+	defer cleanup()
 
-	// 执行预秒传
-	preHash, err := d.ComputePreHash(options.File)
-	if err != nil {
+	uploadOptions := options.UploadFileOptions
+	uploadOptions.Size = size
+
+	proofStart, proofEnd := proofRangeV1(credential, size)
+
+	hasher := NewHasher(&HasherOptions{
+		Crc64:      options.Crc64,
+		ProofStart: proofStart,
+		ProofEnd:   proofEnd,
+	})
+
+	reader := &hashingReader{
+		reader: io.NewSectionReader(source, 0, size),
+		hasher: hasher,
+	}
+
+	// 执行预秒传，读取的内容同时计入完整 HASH
+	_, err = io.CopyN(io.Discard, reader, preHashSize)
+	if err != nil && err != io.EOF {
 		return nil, false, err
 	}
 
+	preHash := hasher.PreHash()
+
 	// 对于空文件，回退到普通上传
-	if options.Size <= 0 {
+	if size <= 0 {
 		preHash = ""
 	}
 
-	response, err := d.CreateWithFolders(credential, &CreateWithFoldersOptions{
+	response, err := d.CreateWithFoldersWithContext(ctx, credential, &CreateWithFoldersOptions{
+		DriveId:      options.DriveId,
 		ParentFileId: options.ParentFileId,
 		Name:         options.Name,
-		Size:         options.Size,
+		Size:         size,
 		PreHash:      preHash,
+		PartSize:     options.PartSize,
 	})
 
 	doneFn := func(info *ProgressInfo) {
@@ -491,40 +956,60 @@ func (d *AliyunDrive) UploadFileRapid(credential *Credential, options *UploadFil
 	// 返回 rapid_upload=false，则表明预秒传没有匹配到对应的数据，直接上传数据
 	if !preHashMatch {
 		if preHashResp, ok := response.(*models.CreateWithFoldersPreHashResponse); ok && !preHashResp.RapidUpload {
-			file, err = d.uploadParts(credential, &uploadPartsOptions{
+			session, err := d.newUploadSession(credential, &uploadOptions,
+				preHashResp.FileId, preHashResp.UploadId, preHashResp.PartInfoList)
+			if err != nil {
+				return nil, false, err
+			}
+
+			file, err = d.uploadParts(ctx, credential, &uploadPartsOptions{
+				driveId:          options.DriveId,
 				fileId:           preHashResp.FileId,
 				uploadId:         preHashResp.UploadId,
 				partInfoList:     preHashResp.PartInfoList,
-				reader:           options.File,
+				reader:           reader,
+				session:          session,
+				sessionStore:     options.SessionStore,
 				progressCallback: options.ProgressCallback,
 				progressDone:     doneFn,
 			})
+			if err != nil {
+				return nil, false, err
+			}
 
-			return file, false, err
+			return file, false, verifyChecksum(file, hasher, size)
 		}
 	}
 
 	// 表明预秒传匹配到可能的数据，再次调用秒传流程
-	proofCodeV1, err := d.ComputeProofCodeV1(credential, options.File, options.Size)
-	if err != nil {
-		return nil, false, err
-	}
-
-	// 如果已经提供内容 HASH，不用重复计算
 	contentSha1 := options.ContentHash
+	proofCodeV1 := ""
+
 	if options.ContentHash == "" {
-		contentSha1, err = ChecksumFileSha1(options.File)
+		// 从预秒传读取的位置继续，一次读取计算 SHA1 和 proof code
+		_, err = io.Copy(io.Discard, reader)
+		if err != nil {
+			return nil, false, err
+		}
+
+		contentSha1 = hasher.Sha1()
+		proofCodeV1 = hasher.ProofCode()
+	} else {
+		// 已经提供内容 HASH，只需要读取 proof code
+		proofCodeV1, err = d.ComputeProofCodeV1(credential, source, size)
 		if err != nil {
 			return nil, false, err
 		}
 	}
 
-	response, err = d.CreateWithFolders(credential, &CreateWithFoldersOptions{
+	response, err = d.CreateWithFoldersWithContext(ctx, credential, &CreateWithFoldersOptions{
+		DriveId:      options.DriveId,
 		ParentFileId: options.ParentFileId,
 		Name:         options.Name,
-		Size:         options.Size,
+		Size:         size,
 		ProofCode:    proofCodeV1,
 		ContentHash:  strings.ToUpper(contentSha1),
+		PartSize:     options.PartSize,
 	})
 	if err != nil {
 		return nil, false, err
@@ -533,7 +1018,7 @@ func (d *AliyunDrive) UploadFileRapid(credential *Credential, options *UploadFil
 	proofResp := response.(*models.CreateWithFoldersWithProofResponse)
 
 	if proofResp.RapidUpload {
-		file, err := d.GetFile(credential, proofResp.FileId)
+		file, err := d.GetFileInDriveWithContext(ctx, credential, options.DriveId, proofResp.FileId)
 		if err != nil {
 			return nil, false, err
 		}
@@ -548,19 +1033,49 @@ func (d *AliyunDrive) UploadFileRapid(credential *Credential, options *UploadFil
 	}
 
 	// 最后如果秒传还是失败，说明预秒传 HASH 碰撞了，直接上传
-	file, err = d.uploadParts(credential, &uploadPartsOptions{
+	session, err := d.newUploadSession(credential, &uploadOptions,
+		proofResp.FileId, proofResp.UploadId, proofResp.PartInfoList)
+	if err != nil {
+		return nil, false, err
+	}
+
+	file, err = d.uploadParts(ctx, credential, &uploadPartsOptions{
+		driveId:          options.DriveId,
 		fileId:           proofResp.FileId,
 		uploadId:         proofResp.UploadId,
 		partInfoList:     proofResp.PartInfoList,
-		reader:           options.File,
+		reader:           reader,
+		session:          session,
+		sessionStore:     options.SessionStore,
 		progressCallback: options.ProgressCallback,
 		progressDone:     doneFn,
 	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return file, false, verifyChecksum(file, hasher, size)
+}
+
+// verifyChecksum 校验上传完成的文件与本地计算的 HASH，未读取完整文件或服务端未返回 HASH 时跳过
+func verifyChecksum(file *models.File, hasher *Hasher, size int64) error {
+	if hasher.Size() != size {
+		return nil
+	}
+
+	if file.ContentHash != "" && !strings.EqualFold(file.ContentHash, hasher.Sha1()) {
+		return fmt.Errorf("%w: file %s sha1 %s, local %s", ErrChecksumMismatch, file.FileId, file.ContentHash, hasher.Sha1())
+	}
+
+	if crc := hasher.Crc64(); crc != "" && file.Crc64Hash != "" && file.Crc64Hash != crc {
+		return fmt.Errorf("%w: file %s crc64 %s, local %s", ErrChecksumMismatch, file.FileId, file.Crc64Hash, crc)
+	}
 
-	return file, false, err
+	return nil
 }
 
 type UploadFileOptions struct {
+	DriveId          string // 上传到的 Drive，为空时使用 credential.DefaultDriveId
 	Name             string
 	Size             int64
 	ParentFileId     string
@@ -568,14 +1083,55 @@ type UploadFileOptions struct {
 	ProgressCallback ProgressCallback
 	ProgressDone     func(info *ProgressInfo)
 	Reader           io.Reader
+	PartSize         int64 // 分片大小，为 0 时根据 Size 自动计算，分片数量不能超过 models.MaxPartCount
+
+	// SessionStore 设置后每上传完一个分片保存一次进度，中断后可通过 ResumeUpload 继续
+	SessionStore UploadSessionStore
+	// SessionKey 上传进度的存储 Key，为空时使用 DriveId、ParentFileId 和 Name 生成
+	SessionKey string
+}
+
+// newUploadSession 创建上传进度，未设置 SessionStore 时返回 nil
+func (d *AliyunDrive) newUploadSession(credential *Credential, options *UploadFileOptions,
+	fileId, uploadId string, partInfoList []*models.PartInfo) (*UploadSession, error) {
+	if options.SessionStore == nil {
+		return nil, nil
+	}
+
+	driveId := d.driveId(credential, options.DriveId)
+
+	key := options.SessionKey
+	if key == "" {
+		key = uploadSessionKey(driveId, options.ParentFileId, options.Name)
+	}
+
+	session := &UploadSession{
+		Key:          key,
+		DriveId:      driveId,
+		ParentFileId: options.ParentFileId,
+		Name:         options.Name,
+		Size:         options.Size,
+		FileId:       fileId,
+		UploadId:     uploadId,
+		PartInfoList: partInfoList,
+	}
+
+	return session, options.SessionStore.Save(session)
 }
 
 // UploadFile 同步上传文件（非秒传）
 func (d *AliyunDrive) UploadFile(credential *Credential, options *UploadFileOptions) (*models.File, error) {
-	response, err := d.CreateWithFolders(credential, &CreateWithFoldersOptions{
+	return d.UploadFileWithContext(context.Background(), credential, options)
+}
+
+// UploadFileWithContext 同 UploadFile，通过 ctx 控制取消和超时
+func (d *AliyunDrive) UploadFileWithContext(ctx context.Context, credential *Credential, options *UploadFileOptions) (*models.File, error) {
+	response, err := d.CreateWithFoldersWithContext(ctx, credential, &CreateWithFoldersOptions{
+		DriveId:      options.DriveId,
 		ParentFileId: options.ParentFileId,
 		Name:         options.Name,
 		Size:         options.Size,
+		PartSize:     options.PartSize,
 	})
 
 	if err != nil {
@@ -593,11 +1149,19 @@ func (d *AliyunDrive) UploadFile(credential *Credential, options *UploadFileOpti
 		})
 	}
 
-	return d.uploadParts(credential, &uploadPartsOptions{
+	session, err := d.newUploadSession(credential, options, preHashResp.FileId, preHashResp.UploadId, preHashResp.PartInfoList)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.uploadParts(ctx, credential, &uploadPartsOptions{
+		driveId:          options.DriveId,
 		fileId:           preHashResp.FileId,
 		uploadId:         preHashResp.UploadId,
 		partInfoList:     preHashResp.PartInfoList,
 		reader:           options.Reader,
+		session:          session,
+		sessionStore:     options.SessionStore,
 		progressCallback: options.ProgressCallback,
 		progressDone: func(info *ProgressInfo) {
 			// 更新目录缓存
@@ -612,17 +1176,26 @@ func (d *AliyunDrive) UploadFile(credential *Credential, options *UploadFileOpti
 
 type uploadPartsOptions struct {
 	reader           io.Reader
+	driveId          string
 	partInfoList     []*models.PartInfo
 	fileId           string
 	uploadId         string
+	session          *UploadSession     // 为空时不记录上传进度
+	sessionStore     UploadSessionStore // 为空时只在 session 中记录进度，不保存
 	progressCallback ProgressCallback
 	progressDone     func(info *ProgressInfo)
 }
 
-// uploadParts 上传分片并合并文件
-func (d *AliyunDrive) uploadParts(credential *Credential, options *uploadPartsOptions) (*models.File, error) {
-	if file, ok := options.reader.(*os.File); ok {
-		_, err := file.Seek(0, 0)
+// uploadParts 上传分片并合并文件，reader 支持 Seek 时从第一个分片的开始位置读取
+func (d *AliyunDrive) uploadParts(ctx context.Context, credential *Credential, options *uploadPartsOptions) (*models.File, error) {
+	if seeker, ok := options.reader.(io.Seeker); ok {
+		var offset int64
+
+		if len(options.partInfoList) > 0 {
+			offset = options.partInfoList[0].StartOffset
+		}
+
+		_, err := seeker.Seek(offset, io.SeekStart)
 		if err != nil {
 			return nil, err
 		}
@@ -635,26 +1208,51 @@ func (d *AliyunDrive) uploadParts(credential *Credential, options *uploadPartsOp
 		bufferSize = info.PartSize
 	}
 
-	for _, info := range options.partInfoList {
+	for i, info := range options.partInfoList {
 		if info.PartSize == 0 {
 			continue
 		}
 
-		err := d.PartUpload(credential, *info.UploadUrl, io.LimitReader(options.reader, bufferSize), options.progressCallback)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		err := d.uploadPart(ctx, credential, options, options.partInfoList[i:], bufferSize)
 		if err != nil {
 			return nil, err
 		}
+
+		d.logger.Debug("part uploaded", "file_id", options.fileId, "part_number", info.PartNumber,
+			"part_count", len(options.partInfoList))
+
+		if options.session != nil {
+			info.IsUploaded = true
+			options.session.UploadedSize += info.EndOffset - info.StartOffset + 1
+		}
+
+		if options.session != nil && options.sessionStore != nil {
+			if err := options.sessionStore.Save(options.session); err != nil {
+				d.logger.Warn("save upload session error", "key", options.session.Key, "error", err)
+			}
+		}
 	}
 
-	uploadResp, err := d.CompleteUpload(credential, options.fileId, options.uploadId)
+	uploadResp, err := d.CompleteUploadInDriveWithContext(ctx, credential, options.driveId, options.fileId, options.uploadId)
 	if err != nil {
 		return nil, err
 	}
 
 	if uploadResp.Code != "" || uploadResp.Status != models.FileStatusAvailable {
-		logrus.Errorf("upload file error %v", uploadResp)
+		d.logger.Error("complete upload error", "file_id", uploadResp.FileId, "status", uploadResp.Status,
+			"code", uploadResp.Code, "message", uploadResp.Message)
 
-		return nil, errors.New(fmt.Sprintf("upload file id: %s, error: %s", uploadResp.FileId, uploadResp.Message))
+		return nil, fmt.Errorf("upload file id: %s, status: %s, error: %s", uploadResp.FileId, uploadResp.Status, uploadResp.Message)
+	}
+
+	if options.session != nil && options.sessionStore != nil {
+		if err := options.sessionStore.Delete(options.session.Key); err != nil {
+			d.logger.Warn("delete upload session error", "key", options.session.Key, "error", err)
+		}
 	}
 
 	if options.progressDone != nil {
@@ -668,23 +1266,110 @@ func (d *AliyunDrive) uploadParts(credential *Credential, options *uploadPartsOp
 	return &uploadResp.File, nil
 }
 
+// partUploadRetry 单个分片上传失败后的重试次数
+const partUploadRetry = 3
+
+// partUploadRetryInterval 分片上传失败后重试的间隔
+var partUploadRetryInterval = time.Second
+
+// uploadPart 上传 parts 中的第一个分片，失败时重新获取剩余分片的上传地址并重试。
+// reader 不支持 Seek 时先将分片读入内存，以便重试时重新发送；重试时已回调过的数据不会重复回调进度
+func (d *AliyunDrive) uploadPart(ctx context.Context, credential *Credential, options *uploadPartsOptions,
+	parts []*models.PartInfo, bufferSize int64) error {
+	info := parts[0]
+
+	seeker, seekable := options.reader.(io.Seeker)
+	progress := &retryProgress{callback: options.progressCallback}
+
+	var buffer []byte
+
+	if !seekable {
+		data, err := io.ReadAll(io.LimitReader(options.reader, bufferSize))
+		if err != nil {
+			return err
+		}
+
+		buffer = data
+	}
+
+	for retry := 0; ; retry++ {
+		var reader io.Reader
+
+		if seekable {
+			reader = io.LimitReader(options.reader, bufferSize)
+		} else {
+			reader = bytes.NewReader(buffer)
+		}
+
+		err := d.PartUploadWithContext(ctx, credential, *info.UploadUrl, reader, progress.attempt())
+		if err == nil || retry >= partUploadRetry || ctx.Err() != nil || errors.Is(err, errUserStop) {
+			return err
+		}
+
+		d.logger.Warn("upload part error", "file_id", options.fileId, "part_number", info.PartNumber,
+			"error", err, "retry", retry+1)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(partUploadRetryInterval):
+		}
+
+		if seekable {
+			if _, err := seeker.Seek(info.StartOffset, io.SeekStart); err != nil {
+				return err
+			}
+		}
+
+		// 上传地址过期或上传失败时刷新剩余分片的上传地址
+		var partNumbers []int
+
+		for _, part := range parts {
+			partNumbers = append(partNumbers, part.PartNumber)
+		}
+
+		resp, err := d.GetUploadUrlInDriveWithContext(ctx, credential, options.driveId, options.fileId, options.uploadId, partNumbers)
+		if err != nil {
+			return err
+		}
+
+		updateUploadUrls(parts, resp.PartInfoList)
+	}
+}
+
 // RenameFile 重命名文件
 func (d *AliyunDrive) RenameFile(credential *Credential, fileId, name string) (*models.RenameFileResponse, error) {
+	return d.RenameFileWithContext(context.Background(), credential, fileId, name)
+}
+
+// RenameFileWithContext 同 RenameFile，通过 ctx 控制取消和超时
+func (d *AliyunDrive) RenameFileWithContext(ctx context.Context, credential *Credential, fileId, name string) (*models.RenameFileResponse, error) {
+	return d.RenameFileInDriveWithContext(ctx, credential, "", fileId, name)
+}
+
+// RenameFileInDrive 同 RenameFile，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) RenameFileInDrive(credential *Credential, driveId, fileId, name string) (*models.RenameFileResponse, error) {
+	return d.RenameFileInDriveWithContext(context.Background(), credential, driveId, fileId, name)
+}
+
+// RenameFileInDriveWithContext 同 RenameFileInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) RenameFileInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId, name string) (*models.RenameFileResponse, error) {
 	request := models.NewRenameFileRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 	request.FileId = fileId
 	request.Name = name
 
 	var resp models.RenameFileResponse
 
-	err := d.send(credential, request, &resp)
+	parentFileId := d.parentFileId(ctx, credential, driveId, fileId)
 
-	if err == nil {
-		file, _ := d.GetFile(credential, fileId)
+	err := d.send(ctx, credential, request, &resp)
 
+	if err == nil {
 		d.EvictCacheWithPrefix(fileId)
-		d.EvictCacheWithPrefix(file.ParentFileId)
+		d.EvictCacheWithPrefix(parentFileId)
+		d.evictPathCache()
 	}
 
 	return &resp, err
@@ -692,54 +1377,212 @@ func (d *AliyunDrive) RenameFile(credential *Credential, fileId, name string) (*
 
 // MoveFile 移动单个文件
 func (d *AliyunDrive) MoveFile(credential *Credential, fileId, toParentFileId string) (*http.BaseResponse, error) {
+	return d.MoveFileWithContext(context.Background(), credential, fileId, toParentFileId)
+}
+
+// MoveFileWithContext 同 MoveFile，通过 ctx 控制取消和超时
+func (d *AliyunDrive) MoveFileWithContext(ctx context.Context, credential *Credential, fileId, toParentFileId string) (*http.BaseResponse, error) {
+	return d.MoveFileInDriveWithContext(ctx, credential, "", fileId, toParentFileId)
+}
+
+// MoveFileInDrive 同 MoveFile，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) MoveFileInDrive(credential *Credential, driveId, fileId, toParentFileId string) (*http.BaseResponse, error) {
+	return d.MoveFileInDriveWithContext(context.Background(), credential, driveId, fileId, toParentFileId)
+}
+
+// MoveFileInDriveWithContext 同 MoveFileInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) MoveFileInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId, toParentFileId string) (*http.BaseResponse, error) {
 	request := models.NewMoveFileRequest()
 
-	request.DriveId = credential.DefaultDriveId
-	request.ToDriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
+	request.ToDriveId = d.driveId(credential, driveId)
 	request.FileId = fileId
 	request.ToParentFileId = toParentFileId
 
 	var resp http.BaseResponse
 
-	err := d.send(credential, request, &resp)
+	parentFileId := d.parentFileId(ctx, credential, driveId, fileId)
 
-	if err == nil {
-		file, _ := d.GetFile(credential, fileId)
+	err := d.send(ctx, credential, request, &resp)
 
+	if err == nil {
 		d.EvictCacheWithPrefix(fileId)
-		d.EvictCacheWithPrefix(file.ParentFileId)
+		d.EvictCacheWithPrefix(parentFileId)
 		d.EvictCacheWithPrefix(toParentFileId)
+		d.evictPathCache()
+	}
+
+	return &resp, err
+}
+
+// CopyFile 复制文件或目录到 toParentFileId 目录，newName 为空时保持原名，同名时自动重命名
+// 复制目录时服务端异步执行，会等待异步任务完成后返回
+func (d *AliyunDrive) CopyFile(credential *Credential, fileId, toParentFileId, newName string) (*models.CopyFileResponse, error) {
+	return d.CopyFileWithContext(context.Background(), credential, fileId, toParentFileId, newName)
+}
+
+// CopyFileWithContext 同 CopyFile，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CopyFileWithContext(ctx context.Context, credential *Credential, fileId, toParentFileId, newName string) (*models.CopyFileResponse, error) {
+	return d.CopyFileInDriveWithContext(ctx, credential, "", fileId, toParentFileId, newName)
+}
+
+// CopyFileInDrive 同 CopyFile，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) CopyFileInDrive(credential *Credential, driveId, fileId, toParentFileId, newName string) (*models.CopyFileResponse, error) {
+	return d.CopyFileInDriveWithContext(context.Background(), credential, driveId, fileId, toParentFileId, newName)
+}
+
+// CopyFileInDriveWithContext 同 CopyFileInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CopyFileInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId, toParentFileId, newName string) (*models.CopyFileResponse, error) {
+	return d.CopyFileToDriveWithContext(ctx, credential, driveId, fileId, driveId, toParentFileId, newName)
+}
+
+// CopyFileToDrive 复制 driveId 中的文件或目录到 toDriveId 的 toParentFileId 目录，可用于跨 Drive 复制，
+// driveId、toDriveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) CopyFileToDrive(credential *Credential, driveId, fileId, toDriveId, toParentFileId, newName string) (*models.CopyFileResponse, error) {
+	return d.CopyFileToDriveWithContext(context.Background(), credential, driveId, fileId, toDriveId, toParentFileId, newName)
+}
+
+// CopyFileToDriveWithContext 同 CopyFileToDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CopyFileToDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId, toDriveId, toParentFileId, newName string) (*models.CopyFileResponse, error) {
+	request := models.NewCopyFileRequest()
+
+	request.DriveId = d.driveId(credential, driveId)
+	request.ToDriveId = d.driveId(credential, toDriveId)
+	request.FileId = fileId
+	request.ToParentFileId = toParentFileId
+	request.NewName = newName
+
+	var resp models.CopyFileResponse
+
+	err := d.send(ctx, credential, request, &resp)
+	if err != nil {
+		return &resp, err
+	}
+
+	if resp.AsyncTaskId != "" {
+		_, err = d.WaitAsyncTaskWithContext(ctx, credential, resp.AsyncTaskId)
 	}
 
+	d.EvictCacheWithPrefix(toParentFileId)
+
 	return &resp, err
 }
 
+// asyncTaskPollInterval 查询异步任务状态的间隔
+var asyncTaskPollInterval = time.Second
+
+// GetAsyncTask 查询异步任务状态
+func (d *AliyunDrive) GetAsyncTask(credential *Credential, asyncTaskId string) (*models.AsyncTaskResponse, error) {
+	return d.GetAsyncTaskWithContext(context.Background(), credential, asyncTaskId)
+}
+
+// GetAsyncTaskWithContext 同 GetAsyncTask，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetAsyncTaskWithContext(ctx context.Context, credential *Credential, asyncTaskId string) (*models.AsyncTaskResponse, error) {
+	request := models.NewAsyncTaskRequest()
+
+	request.AsyncTaskId = asyncTaskId
+
+	var resp models.AsyncTaskResponse
+
+	err := d.send(ctx, credential, request, &resp)
+
+	return &resp, err
+}
+
+// WaitAsyncTask 等待异步任务结束，任务失败时返回错误
+func (d *AliyunDrive) WaitAsyncTask(credential *Credential, asyncTaskId string) (*models.AsyncTaskResponse, error) {
+	return d.WaitAsyncTaskWithContext(context.Background(), credential, asyncTaskId)
+}
+
+// WaitAsyncTaskWithContext 同 WaitAsyncTask，通过 ctx 控制取消和超时
+func (d *AliyunDrive) WaitAsyncTaskWithContext(ctx context.Context, credential *Credential, asyncTaskId string) (*models.AsyncTaskResponse, error) {
+	for {
+		resp, err := d.GetAsyncTaskWithContext(ctx, credential, asyncTaskId)
+		if err != nil {
+			return resp, err
+		}
+
+		switch resp.State {
+		case models.AsyncTaskStateSucceed:
+			return resp, nil
+		case models.AsyncTaskStateFailed:
+			return resp, http.NewAliyunDriveError(resp.ErrCode, fmt.Sprintf("async task %s failed: %s", asyncTaskId, resp.Message))
+		}
+
+		select {
+		case <-ctx.Done():
+			return resp, ctx.Err()
+		case <-time.After(asyncTaskPollInterval):
+		}
+	}
+}
+
 // RemoveFile 删除文件
 func (d *AliyunDrive) RemoveFile(credential *Credential, fileId string) (*http.BaseResponse, error) {
+	return d.RemoveFileWithContext(context.Background(), credential, fileId)
+}
+
+// RemoveFileWithContext 同 RemoveFile，通过 ctx 控制取消和超时
+func (d *AliyunDrive) RemoveFileWithContext(ctx context.Context, credential *Credential, fileId string) (*http.BaseResponse, error) {
+	return d.RemoveFileInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// RemoveFileInDrive 同 RemoveFile，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) RemoveFileInDrive(credential *Credential, driveId, fileId string) (*http.BaseResponse, error) {
+	return d.RemoveFileInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// RemoveFileInDriveWithContext 同 RemoveFileInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) RemoveFileInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) (*http.BaseResponse, error) {
 	request := models.NewRemoveFileRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 	request.FileId = fileId
 
 	var resp http.BaseResponse
 
-	err := d.send(credential, request, &resp)
+	parentFileId := d.parentFileId(ctx, credential, driveId, fileId)
 
-	if err == nil {
-		file, _ := d.GetFile(credential, fileId)
+	err := d.send(ctx, credential, request, &resp)
 
+	if err == nil {
 		d.EvictCacheWithPrefix(fileId)
-		d.EvictCacheWithPrefix(file.ParentFileId)
+		d.EvictCacheWithPrefix(parentFileId)
 	}
 
 	return &resp, err
 }
 
+// parentFileId 获取文件的父目录 ID，用于操作后失效目录缓存，获取失败时返回空
+func (d *AliyunDrive) parentFileId(ctx context.Context, credential *Credential, driveId, fileId string) string {
+	file, err := d.GetFileInDriveWithContext(ctx, credential, driveId, fileId)
+	if err != nil || file.File == nil {
+		return ""
+	}
+
+	return file.ParentFileId
+}
+
 // CreateDirectory 创建目录
 func (d *AliyunDrive) CreateDirectory(credential *Credential, parentFileId, name string) (*models.File, error) {
+	return d.CreateDirectoryWithContext(context.Background(), credential, parentFileId, name)
+}
+
+// CreateDirectoryWithContext 同 CreateDirectory，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CreateDirectoryWithContext(ctx context.Context, credential *Credential, parentFileId, name string) (*models.File, error) {
+	return d.CreateDirectoryInDriveWithContext(ctx, credential, "", parentFileId, name)
+}
+
+// CreateDirectoryInDrive 同 CreateDirectory，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) CreateDirectoryInDrive(credential *Credential, driveId, parentFileId, name string) (*models.File, error) {
+	return d.CreateDirectoryInDriveWithContext(context.Background(), credential, driveId, parentFileId, name)
+}
+
+// CreateDirectoryInDriveWithContext 同 CreateDirectoryInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CreateDirectoryInDriveWithContext(ctx context.Context, credential *Credential, driveId, parentFileId, name string) (*models.File, error) {
 	request := models.NewCreateWithFoldersPreHashRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 	request.CheckNameMode = models.CheckNameModeRefuse
 	request.Type = models.FileTypeFolder
 	request.ParentFileId = parentFileId
@@ -747,7 +1590,7 @@ func (d *AliyunDrive) CreateDirectory(credential *Credential, parentFileId, name
 
 	var resp models.File
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	d.EvictCacheWithPrefix(parentFileId)
 
@@ -760,23 +1603,11 @@ type ProgressInfo struct {
 	PartInfoList []*models.PartInfo
 }
 
-type RateLimiterReader struct {
-	io.Reader
-	limiter *rate.Limiter
-}
-
-func (p *RateLimiterReader) Read(buf []byte) (n int, err error) {
-	err = p.limiter.WaitN(context.TODO(), len(buf))
-	if err != nil {
-		return 0, err
-	}
-
-	n, err = p.Reader.Read(buf)
-	return n, err
-}
-
 type ProgressCallback func(readCount int64) bool
 
+// errUserStop ProgressCallback 返回 false 时中止上传
+var errUserStop = errors.New("user stop")
+
 type progressReader struct {
 	io.Reader
 	Callback ProgressCallback
@@ -786,8 +1617,40 @@ func (p *progressReader) Read(buf []byte) (n int, err error) {
 	n, err = p.Reader.Read(buf)
 
 	if p.Callback != nil && !p.Callback(int64(n)) {
-		return 0, errors.New("user stop")
+		return 0, errUserStop
 	}
 
 	return n, err
 }
+
+// retryProgress 同一段数据重试时只回调超过之前已回调位置的部分，累计的进度不会超过数据大小
+type retryProgress struct {
+	mu       sync.Mutex
+	callback ProgressCallback
+	reported int64
+}
+
+// attempt 返回一次尝试使用的 ProgressCallback，callback 为空时返回 nil
+func (p *retryProgress) attempt() ProgressCallback {
+	if p.callback == nil {
+		return nil
+	}
+
+	var read int64
+
+	return func(readCount int64) bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		read += readCount
+
+		var count int64
+
+		if read > p.reported {
+			count = read - p.reported
+			p.reported = read
+		}
+
+		return p.callback(count)
+	}
+}
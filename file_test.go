@@ -1,12 +1,22 @@
 package aliyundrive
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/jakeslee/aliyundrive/drivetest"
 	http2 "github.com/jakeslee/aliyundrive/http"
 	"github.com/jakeslee/aliyundrive/models"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func GetClientAndCred() (*AliyunDrive, *Credential, error) {
@@ -24,6 +34,8 @@ func GetClientAndCred() (*AliyunDrive, *Credential, error) {
 }
 
 func TestGetByPath(t *testing.T) {
+	requireRefreshToken(t)
+
 	cred, credential, err := GetClientAndCred()
 
 	if err != nil {
@@ -36,7 +48,7 @@ func TestGetByPath(t *testing.T) {
 
 	var resp models.FileResponse
 
-	err = cred.send(credential, request, &resp)
+	err = cred.send(context.Background(), credential, request, &resp)
 
 	if err != nil {
 		t.Fatalf("cred %v", err)
@@ -44,6 +56,8 @@ func TestGetByPath(t *testing.T) {
 }
 
 func TestGetDownloadURL(t *testing.T) {
+	requireRefreshToken(t)
+
 	drive := NewClient(&Options{
 		AutoRefresh: true,
 	})
@@ -63,6 +77,8 @@ func TestGetDownloadURL(t *testing.T) {
 }
 
 func TestAliyunDrive_UploadFileRapid(t *testing.T) {
+	requireRefreshToken(t)
+
 	filePath := "/Volumes/Downloads/视频资源/小林家的龙女仆/第02季/小林家的龙女仆.第二季.日语中字.2021.HD1080P.X264.AAC-YYDS/S02E11.mp4"
 	stat, _ := os.Stat(filePath)
 	name := stat.Name()
@@ -97,6 +113,8 @@ func TestAliyunDrive_UploadFileRapid(t *testing.T) {
 }
 
 func TestAliyunDrive_UploadFile(t *testing.T) {
+	requireRefreshToken(t)
+
 	filePath := "/Volumes/Downloads/untitled folder/阿里小白羊版Mac v2.8.ccc.zip"
 	stat, _ := os.Stat(filePath)
 
@@ -135,6 +153,8 @@ func TestAliyunDrive_UploadFile(t *testing.T) {
 }
 
 func TestAliyunDrive_Download(t *testing.T) {
+	requireRefreshToken(t)
+
 	drive, cred, err := GetClientAndCred()
 
 	if err != nil {
@@ -185,10 +205,12 @@ func (d *AliyunDrive) Test(credential *Credential) {
 
 	var resp models.FolderFilesResponse
 
-	_ = d.send(credential, request, &resp)
+	_ = d.send(context.Background(), credential, request, &resp)
 }
 
 func TestTest(t *testing.T) {
+	requireRefreshToken(t)
+
 	drive, cred, err := GetClientAndCred()
 
 	if err != nil {
@@ -203,3 +225,549 @@ func TestTest(t *testing.T) {
 	//dir := filepath.Dir(filepath.Clean(s))
 	//t.Log(split, dir)
 }
+
+func TestAliyunDrive_GetFolderFiles(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	folder := server.AddFolder(DefaultRootFileId, "docs")
+	server.AddFile(folder.FileId, "a.txt", []byte("a"))
+	server.AddFile(folder.FileId, "b.txt", []byte("bb"))
+
+	files, err := drive.GetFolderFiles(cred, &FolderFilesOptions{
+		FolderFileId:   folder.FileId,
+		OrderBy:        "name",
+		OrderDirection: models.OrderDirectionTypeAscend,
+	})
+	if err != nil {
+		t.Fatalf("get folder files error %v", err)
+	}
+
+	if len(files.Items) != 2 || files.Items[0].Name != "a.txt" || files.Items[1].Size != 2 {
+		t.Errorf("unexpected items %+v", files.Items)
+	}
+}
+
+func TestAliyunDrive_ResolvePathToFileId(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	a := server.AddFolder(DefaultRootFileId, "a")
+	b := server.AddFolder(a.FileId, "b")
+	c := server.AddFile(b.FileId, "c.gz", []byte("c"))
+
+	fileId, foundPath, err := drive.ResolvePathToFileId(cred, "/a/b/c.gz")
+	if err != nil || fileId != c.FileId || foundPath != "/a/b/c.gz" {
+		t.Errorf("resolve path got %s, %s, %v", fileId, foundPath, err)
+	}
+
+	fileId, foundPath, err = drive.ResolvePathToFileId(cred, "/a/x/c.gz")
+	if err != ErrPartialFoundPath || fileId != a.FileId || foundPath != "/a" {
+		t.Errorf("resolve partial path got %s, %s, %v", fileId, foundPath, err)
+	}
+
+	resp, err := drive.GetByPath(cred, "a/b")
+	if err != nil || resp.FileId != b.FileId {
+		t.Errorf("get by path got %+v, %v", resp, err)
+	}
+}
+
+func TestAliyunDrive_UploadFileParts(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	content := bytes.Repeat([]byte("0123456789"), ThunkSizeDefault/10*2+1024)
+
+	file, err := drive.UploadFile(cred, &UploadFileOptions{
+		Name:         "parts.bin",
+		Size:         int64(len(content)),
+		ParentFileId: DefaultRootFileId,
+		Reader:       bytes.NewReader(content),
+	})
+	if err != nil {
+		t.Fatalf("upload error %v", err)
+	}
+
+	uploaded, ok := server.Content(file.FileId)
+	if !ok || !bytes.Equal(uploaded, content) {
+		t.Errorf("uploaded content mismatch, size %d", len(uploaded))
+	}
+}
+
+func TestAliyunDrive_UploadFilePartSize(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	content := bytes.Repeat([]byte("0123456789"), 1024*1024/10*3+1024)
+
+	var parts []*models.PartInfo
+
+	file, err := drive.UploadFile(cred, &UploadFileOptions{
+		Name:         "part-size.bin",
+		Size:         int64(len(content)),
+		ParentFileId: DefaultRootFileId,
+		Reader:       bytes.NewReader(content),
+		PartSize:     1024 * 1024,
+		ProgressStart: func(info *ProgressInfo) {
+			parts = info.PartInfoList
+		},
+	})
+	if err != nil {
+		t.Fatalf("upload error %v", err)
+	}
+
+	if len(parts) != 4 || parts[3].StartOffset != 3*1024*1024 || parts[3].EndOffset != int64(len(content)-1) {
+		t.Errorf("unexpected part info list %+v", parts)
+	}
+
+	uploaded, ok := server.Content(file.FileId)
+	if !ok || !bytes.Equal(uploaded, content) {
+		t.Errorf("uploaded content mismatch, size %d", len(uploaded))
+	}
+
+	_, err = drive.UploadFile(cred, &UploadFileOptions{
+		Name:         "too-many-parts.bin",
+		Size:         int64(len(content)),
+		ParentFileId: DefaultRootFileId,
+		Reader:       bytes.NewReader(content),
+		PartSize:     256,
+	})
+	if err == nil {
+		t.Errorf("part count over %d should be rejected", models.MaxPartCount)
+	}
+}
+
+func TestAdaptivePartSize(t *testing.T) {
+	for _, size := range []int64{0, 1, ThunkSizeDefault * models.MaxPartCount, ThunkSizeDefault*models.MaxPartCount + 1, 50 << 30, 1 << 40} {
+		partSize := AdaptivePartSize(size)
+
+		if partSize < ThunkSizeDefault || partSize%(1024*1024) != 0 {
+			t.Errorf("unexpected part size %d for size %d", partSize, size)
+		}
+
+		parts, err := models.NewPartInfoList(size, partSize)
+		if err != nil {
+			t.Fatalf("create part info list for size %d error %v", size, err)
+		}
+
+		if size > 0 && (len(parts) > models.MaxPartCount || parts[len(parts)-1].EndOffset != size-1 ||
+			parts[len(parts)-1].PartNumber != len(parts)) {
+			t.Errorf("unexpected parts for size %d, count %d", size, len(parts))
+		}
+	}
+}
+
+func TestAliyunDrive_UploadFileExpiredUrl(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	setInterval(t, &partUploadRetryInterval, 10*time.Millisecond)
+
+	content := bytes.Repeat([]byte("0123456789"), ThunkSizeDefault/10*2+1024)
+
+	for name, reader := range map[string]io.Reader{
+		"seekable":   bytes.NewReader(content),
+		"unseekable": struct{ io.Reader }{bytes.NewReader(content)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var read int64
+			var expired bool
+
+			file, err := drive.UploadFile(cred, &UploadFileOptions{
+				Name:         name + ".bin",
+				Size:         int64(len(content)),
+				ParentFileId: DefaultRootFileId,
+				Reader:       reader,
+				ProgressCallback: func(readCount int64) bool {
+					read += readCount
+
+					// 第二个分片上传过程中地址过期
+					if !expired && read > ThunkSizeDefault {
+						expired = true
+						server.ExpireUploadUrls()
+					}
+
+					return true
+				},
+			})
+			if err != nil {
+				t.Fatalf("upload error %v", err)
+			}
+
+			if !expired {
+				t.Fatalf("upload urls should be expired during upload")
+			}
+
+			if read != int64(len(content)) {
+				t.Errorf("retried part should not be reported twice, progress %d, size %d", read, len(content))
+			}
+
+			uploaded, ok := server.Content(file.FileId)
+			if !ok || !bytes.Equal(uploaded, content) {
+				t.Errorf("uploaded content mismatch, size %d", len(uploaded))
+			}
+		})
+	}
+}
+
+func TestAliyunDrive_PartUploadExpired(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	var uploadUrl string
+
+	_, err := drive.UploadFile(cred, &UploadFileOptions{
+		Name:         "expired.bin",
+		Size:         4,
+		ParentFileId: DefaultRootFileId,
+		Reader:       strings.NewReader("data"),
+		ProgressStart: func(info *ProgressInfo) {
+			uploadUrl = *info.PartInfoList[0].UploadUrl
+		},
+	})
+	if err != nil {
+		t.Fatalf("upload error %v", err)
+	}
+
+	server.ExpireUploadUrls()
+
+	err = drive.PartUpload(cred, uploadUrl, strings.NewReader("data"), nil)
+
+	var partErr *PartUploadError
+	if !errors.As(err, &partErr) || !partErr.Expired() || partErr.Code != "AccessDenied" {
+		t.Errorf("expect expired PartUploadError, got %v", err)
+	}
+}
+
+func TestAliyunDrive_UploadFileRapidMatched(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	content := bytes.Repeat([]byte("rapid"), 1000)
+	server.AddFile(DefaultRootFileId, "origin.bin", content)
+
+	f, err := os.CreateTemp(t.TempDir(), "rapid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	_, _ = f.Write(content)
+
+	file, rapid, err := drive.UploadFileRapid(cred, &UploadFileRapidOptions{
+		UploadFileOptions: UploadFileOptions{
+			Name:         "copy.bin",
+			Size:         int64(len(content)),
+			ParentFileId: DefaultRootFileId,
+		},
+		File: f,
+	})
+	if err != nil {
+		t.Fatalf("upload rapid error %v", err)
+	}
+
+	if !rapid || file.Name != "copy.bin" {
+		t.Errorf("expect rapid upload, got rapid: %v, file: %+v", rapid, file)
+	}
+
+	_, _ = f.Write([]byte("changed"))
+
+	file, rapid, err = drive.UploadFileRapid(cred, &UploadFileRapidOptions{
+		UploadFileOptions: UploadFileOptions{
+			Name:         "changed.bin",
+			Size:         int64(len(content) + 7),
+			ParentFileId: DefaultRootFileId,
+		},
+		File: f,
+	})
+	if err != nil {
+		t.Fatalf("upload error %v", err)
+	}
+
+	uploaded, _ := server.Content(file.FileId)
+	if rapid || len(uploaded) != len(content)+7 {
+		t.Errorf("expect normal upload, got rapid: %v, size: %d", rapid, len(uploaded))
+	}
+}
+
+func TestAliyunDrive_UploadFileRapidReaderAt(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	content := bytes.Repeat([]byte("reader-at"), 1000)
+	server.AddFile(DefaultRootFileId, "origin.bin", content)
+
+	archive := append(append([]byte("header"), content...), []byte("footer")...)
+
+	tempDir := t.TempDir()
+
+	for name, options := range map[string]*UploadFileRapidOptions{
+		"memory":  {File: bytes.NewReader(content)},
+		"section": {File: io.NewSectionReader(bytes.NewReader(archive), 6, int64(len(content)))},
+		"reader": {
+			UploadFileOptions: UploadFileOptions{Reader: struct{ io.Reader }{bytes.NewReader(content)}},
+			TempDir:           tempDir,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			options.Name = name + ".bin"
+			options.ParentFileId = DefaultRootFileId
+
+			if options.File != nil {
+				options.Size = int64(len(content))
+			}
+
+			file, rapid, err := drive.UploadFileRapid(cred, options)
+			if err != nil {
+				t.Fatalf("upload rapid error %v", err)
+			}
+
+			if !rapid || file.Size != int64(len(content)) {
+				t.Errorf("expect rapid upload, got rapid: %v, file: %+v", rapid, file)
+			}
+		})
+	}
+
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("temp file should be removed, got %d entries", len(entries))
+	}
+
+	// 未匹配时从缓存的临时文件上传
+	unmatched := bytes.Repeat([]byte("unmatched"), 1000)
+
+	file, rapid, err := drive.UploadFileRapid(cred, &UploadFileRapidOptions{
+		UploadFileOptions: UploadFileOptions{
+			Name:         "unmatched.bin",
+			ParentFileId: DefaultRootFileId,
+			Reader:       struct{ io.Reader }{bytes.NewReader(unmatched)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("upload error %v", err)
+	}
+
+	if uploaded, _ := server.Content(file.FileId); rapid || !bytes.Equal(uploaded, unmatched) {
+		t.Errorf("expect normal upload, got rapid: %v, size: %d", rapid, len(uploaded))
+	}
+}
+
+func TestAliyunDrive_MoveRenameRemove(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	folder := server.AddFolder(DefaultRootFileId, "target")
+	file := server.AddFile(DefaultRootFileId, "file.txt", []byte("file"))
+
+	if _, err := drive.MoveFile(cred, file.FileId, folder.FileId); err != nil {
+		t.Fatalf("move error %v", err)
+	}
+
+	if _, err := drive.RenameFile(cred, file.FileId, "renamed.txt"); err != nil {
+		t.Fatalf("rename error %v", err)
+	}
+
+	files, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: folder.FileId})
+	if err != nil || len(files.Items) != 1 || files.Items[0].Name != "renamed.txt" {
+		t.Fatalf("unexpected folder files %+v, %v", files, err)
+	}
+
+	if _, err := drive.RemoveFile(cred, file.FileId); err != nil {
+		t.Fatalf("remove error %v", err)
+	}
+
+	if _, ok := server.File(file.FileId); ok {
+		t.Errorf("file %s should be trashed", file.FileId)
+	}
+}
+
+func TestAliyunDrive_DownloadRange(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	file := server.AddFile(DefaultRootFileId, "download.txt", []byte("0123456789"))
+
+	response, err := drive.Download(cred, file.FileId, "bytes=2-5")
+	if err != nil {
+		t.Fatalf("download error %v", err)
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+
+	if response.StatusCode != http.StatusPartialContent || string(body) != "2345" {
+		t.Errorf("unexpected response %d, %s", response.StatusCode, body)
+	}
+}
+
+func TestAliyunDrive_Endpoints(t *testing.T) {
+	server := drivetest.NewServer()
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	var mu sync.Mutex
+	var paths []string
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mu.Lock()
+		paths = append(paths, request.URL.Path)
+		mu.Unlock()
+
+		request.URL.Path = strings.TrimPrefix(request.URL.Path, "/gateway")
+		proxy.ServeHTTP(writer, request)
+	}))
+	defer gateway.Close()
+
+	drive := NewClient(&Options{
+		Endpoint:         gateway.URL + "/gateway",
+		AuthEndpoint:     gateway.URL + "/gateway/",
+		UploadEndpoint:   gateway.URL + "/gateway",
+		DownloadEndpoint: gateway.URL + "/gateway",
+	})
+
+	cred, err := drive.AddCredential(NewCredential(&Credential{
+		RefreshToken: server.RefreshToken,
+	}))
+	if err != nil {
+		t.Fatalf("add credential error %v", err)
+	}
+
+	file, err := drive.UploadFile(cred, &UploadFileOptions{
+		Name:         "gateway.txt",
+		Size:         7,
+		ParentFileId: DefaultRootFileId,
+		Reader:       strings.NewReader("gateway"),
+	})
+	if err != nil {
+		t.Fatalf("upload error %v", err)
+	}
+
+	response, err := drive.Download(cred, file.FileId, "")
+	if err != nil {
+		t.Fatalf("download error %v", err)
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+	if string(body) != "gateway" {
+		t.Errorf("unexpected download content %s", body)
+	}
+
+	expected := map[string]bool{
+		"/gateway/v2/account/token":                 false,
+		"/gateway/adrive/v2/file/createWithFolders": false,
+		"/gateway/upload/":                          false,
+		"/gateway/v2/file/complete":                 false,
+		"/gateway/download/":                        false,
+	}
+
+	for _, p := range paths {
+		for prefix := range expected {
+			if strings.HasPrefix(p, prefix) {
+				expected[prefix] = true
+			}
+		}
+	}
+
+	for prefix, seen := range expected {
+		if !seen {
+			t.Errorf("request %s not sent through gateway, got %v", prefix, paths)
+		}
+	}
+}
+
+func TestAliyunDrive_WithContextCanceled(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	file := server.AddFile(DefaultRootFileId, "cancel.txt", []byte("cancel"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := drive.GetFolderFilesWithContext(ctx, cred, &FolderFilesOptions{
+		FolderFileId: DefaultRootFileId,
+	}); !errors.Is(err, context.Canceled) {
+		t.Errorf("get folder files expect canceled, got %v", err)
+	}
+
+	if _, err := drive.DownloadWithContext(ctx, cred, file.FileId, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("download expect canceled, got %v", err)
+	}
+
+	uploadCtx, uploadCancel := context.WithCancel(context.Background())
+	defer uploadCancel()
+
+	_, err := drive.UploadFileWithContext(uploadCtx, cred, &UploadFileOptions{
+		Name:         "cancel.bin",
+		Size:         ThunkSizeDefault * 2,
+		ParentFileId: DefaultRootFileId,
+		Reader:       bytes.NewReader(make([]byte, ThunkSizeDefault*2)),
+		ProgressCallback: func(readCount int64) bool {
+			uploadCancel()
+
+			return true
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("upload expect canceled, got %v", err)
+	}
+}
+
+func TestAliyunDrive_GetFullPath(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	a := server.AddFolder(DefaultRootFileId, "a")
+	b := server.AddFolder(a.FileId, "b")
+	c := server.AddFile(b.FileId, "c.txt", []byte("c"))
+
+	resp, err := drive.GetPath(cred, c.FileId)
+	if err != nil || len(resp.Items) != 3 || resp.Items[0].FileId != c.FileId || resp.Items[2].FileId != a.FileId {
+		t.Fatalf("unexpected path %+v, %v", resp, err)
+	}
+
+	fullPath, err := drive.GetFullPath(cred, c.FileId)
+	if err != nil || fullPath != "/a/b/c.txt" {
+		t.Errorf("unexpected full path %s, %v", fullPath, err)
+	}
+
+	if _, err := drive.RenameFile(cred, a.FileId, "x"); err != nil {
+		t.Fatalf("rename error %v", err)
+	}
+
+	fullPath, err = drive.GetFullPath(cred, c.FileId)
+	if err != nil || fullPath != "/x/b/c.txt" {
+		t.Errorf("path cache should be evicted after rename, got %s, %v", fullPath, err)
+	}
+
+	if fullPath, _ := drive.GetFullPath(cred, DefaultRootFileId); fullPath != "/" {
+		t.Errorf("unexpected root path %s", fullPath)
+	}
+}
+
+func TestAliyunDrive_CopyFile(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	setInterval(t, &asyncTaskPollInterval, 10*time.Millisecond)
+
+	src := server.AddFolder(DefaultRootFileId, "src")
+	file := server.AddFile(src.FileId, "file.txt", []byte("copy"))
+
+	if _, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId}); err != nil {
+		t.Fatalf("get folder files error %v", err)
+	}
+
+	resp, err := drive.CopyFile(cred, src.FileId, DefaultRootFileId, "dst")
+	if err != nil || resp.AsyncTaskId == "" {
+		t.Fatalf("copy folder got %+v, %v", resp, err)
+	}
+
+	files, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId})
+	if err != nil || len(files.Items) != 2 {
+		t.Fatalf("root folder cache should be evicted, got %+v, %v", files, err)
+	}
+
+	if children := server.Children(resp.FileId); len(children) != 1 || children[0].Name != "file.txt" {
+		t.Errorf("unexpected copied children %+v", children)
+	}
+
+	resp, err = drive.CopyFileToDrive(cred, "", file.FileId, server.SboxDriveId, DefaultRootFileId, "")
+	if err != nil || resp.DriveId != server.SboxDriveId {
+		t.Fatalf("copy to drive got %+v, %v", resp, err)
+	}
+
+	if children := server.DriveChildren(server.SboxDriveId, DefaultRootFileId); len(children) != 1 ||
+		children[0].ContentHash != file.ContentHash {
+		t.Errorf("unexpected sbox children %+v", children)
+	}
+}
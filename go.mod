@@ -4,17 +4,16 @@ go 1.17
 
 require (
 	github.com/allegro/bigcache/v3 v3.0.0
-	github.com/antonfisher/nested-logrus-formatter v1.3.1
 	github.com/asaskevich/EventBus v0.0.0-20200907212545-49d423059eef
 	github.com/go-resty/resty/v2 v2.6.0
 	github.com/jinzhu/copier v0.3.2
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/sirupsen/logrus v1.8.1
+	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
 )
 
 require (
 	github.com/allegro/bigcache/v2 v2.2.5 // indirect
 	golang.org/x/net v0.0.0-20210916014120-12bc252f5db8 // indirect
 	golang.org/x/sys v0.0.0-20210915083310-ed5796bab164 // indirect
-	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac // indirect
 )
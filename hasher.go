@@ -0,0 +1,134 @@
+package aliyundrive
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"hash/crc64"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// preHashSize PreHash 只计算文件前 1KB
+const preHashSize = 1024
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+type HasherOptions struct {
+	Crc64      bool  // 是否计算 CRC64
+	ProofStart int64 // proof code 在文件中的开始位置
+	ProofEnd   int64 // proof code 在文件中的结束位置（不包含），与 ProofStart 相同时不计算
+}
+
+// Hasher 顺序写入文件内容，一次读取同时计算 PreHash、SHA1、CRC64 和 proof code
+type Hasher struct {
+	preHash    hash.Hash
+	sha1       hash.Hash
+	crc64      hash.Hash64
+	proof      []byte
+	proofStart int64
+	proofEnd   int64
+	size       int64
+}
+
+func NewHasher(options *HasherOptions) *Hasher {
+	h := &Hasher{
+		preHash:    sha1.New(),
+		sha1:       sha1.New(),
+		proofStart: options.ProofStart,
+		proofEnd:   options.ProofEnd,
+	}
+
+	if options.Crc64 {
+		h.crc64 = crc64.New(crc64Table)
+	}
+
+	return h
+}
+
+func (h *Hasher) Write(p []byte) (int, error) {
+	if h.size < preHashSize {
+		h.preHash.Write(p[:Min(int64(len(p)), preHashSize-h.size)])
+	}
+
+	end := h.size + int64(len(p))
+
+	if h.proofStart < end && h.size < h.proofEnd {
+		from := Max(h.proofStart, h.size) - h.size
+		to := Min(h.proofEnd, end) - h.size
+
+		h.proof = append(h.proof, p[from:to]...)
+	}
+
+	h.sha1.Write(p)
+
+	if h.crc64 != nil {
+		h.crc64.Write(p)
+	}
+
+	h.size = end
+
+	return len(p), nil
+}
+
+// Size 已写入的字节数
+func (h *Hasher) Size() int64 {
+	return h.size
+}
+
+// PreHash 前 1KB 的 SHA1，与 ComputePreHash 结果一致
+func (h *Hasher) PreHash() string {
+	return fmt.Sprintf("%x", h.preHash.Sum(nil))
+}
+
+// Sha1 全部内容的 SHA1，大写
+func (h *Hasher) Sha1() string {
+	return strings.ToUpper(fmt.Sprintf("%x", h.sha1.Sum(nil)))
+}
+
+// Crc64 全部内容的 CRC64（ECMA），与 models.File.Crc64Hash 格式一致，未开启时为空
+func (h *Hasher) Crc64() string {
+	if h.crc64 == nil {
+		return ""
+	}
+
+	return strconv.FormatUint(h.crc64.Sum64(), 10)
+}
+
+// ProofCode proof code v1，与 ComputeProofCodeV1 结果一致
+func (h *Hasher) ProofCode() string {
+	return base64.StdEncoding.EncodeToString(h.proof)
+}
+
+// hashingReader 读取时将未计算过的内容写入 Hasher。
+// 支持 Seek，分片重试等重复读取的内容不会重复计算，保证读到文件末尾时 Hasher 为完整内容的结果
+type hashingReader struct {
+	reader io.ReadSeeker
+	hasher *Hasher
+	offset int64
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	if end := r.offset + int64(n); r.offset <= r.hasher.Size() && r.hasher.Size() < end {
+		_, _ = r.hasher.Write(p[r.hasher.Size()-r.offset : n])
+	}
+
+	r.offset += int64(n)
+
+	return n, err
+}
+
+func (r *hashingReader) Seek(offset int64, whence int) (int64, error) {
+	position, err := r.reader.Seek(offset, whence)
+	if err != nil {
+		return position, err
+	}
+
+	r.offset = position
+
+	return position, nil
+}
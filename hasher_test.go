@@ -0,0 +1,126 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHasher(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdefghijklmnopqrstuvwxyz"), 300)
+
+	f, err := os.CreateTemp(t.TempDir(), "hasher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	_, _ = f.Write(content)
+
+	cred := &Credential{AccessToken: "drivetest-access-token"}
+	start, end := proofRangeV1(cred, int64(len(content)))
+
+	hasher := NewHasher(&HasherOptions{
+		Crc64:      true,
+		ProofStart: start,
+		ProofEnd:   end,
+	})
+
+	reader := &hashingReader{
+		reader: bytes.NewReader(content),
+		hasher: hasher,
+	}
+
+	// 分段读取并回退重读，重复读取的内容不应重复计算
+	buf := make([]byte, 700)
+
+	for {
+		n, err := reader.Read(buf)
+
+		if n == len(buf) {
+			if _, err := reader.Seek(-int64(n/2), io.SeekCurrent); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if hasher.Size() != int64(len(content)) {
+		t.Fatalf("unexpected hashed size %d", hasher.Size())
+	}
+
+	drive := NewClient(&Options{})
+
+	_, _ = f.Seek(0, io.SeekStart)
+	preHash, _ := drive.ComputePreHash(f)
+
+	if hasher.PreHash() != preHash {
+		t.Errorf("pre hash mismatch %s, %s", hasher.PreHash(), preHash)
+	}
+
+	if expect := strings.ToUpper(fmt.Sprintf("%x", sha1.Sum(content))); hasher.Sha1() != expect {
+		t.Errorf("sha1 mismatch %s, %s", hasher.Sha1(), expect)
+	}
+
+	if expect := strconv.FormatUint(crc64.Checksum(content, crc64.MakeTable(crc64.ECMA)), 10); hasher.Crc64() != expect {
+		t.Errorf("crc64 mismatch %s, %s", hasher.Crc64(), expect)
+	}
+
+	if proof, _ := drive.ComputeProofCodeV1(cred, f, int64(len(content))); hasher.ProofCode() != proof {
+		t.Errorf("proof code mismatch %s, %s", hasher.ProofCode(), proof)
+	}
+}
+
+func TestAliyunDrive_UploadFileRapidChecksum(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	origin := bytes.Repeat([]byte("checksum"), 1000)
+	server.AddFile(DefaultRootFileId, "origin.bin", origin)
+
+	// 与 origin 前 1KB 和大小相同，预秒传匹配但秒传失败
+	collision := append([]byte{}, origin...)
+	copy(collision[len(collision)-8:], "collided")
+
+	for name, content := range map[string][]byte{
+		"unmatched": bytes.Repeat([]byte("unmatched"), 1000),
+		"collision": collision,
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, err := os.CreateTemp(t.TempDir(), name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			_, _ = f.Write(content)
+
+			file, rapid, err := drive.UploadFileRapid(cred, &UploadFileRapidOptions{
+				UploadFileOptions: UploadFileOptions{
+					Name:         name + ".bin",
+					Size:         int64(len(content)),
+					ParentFileId: DefaultRootFileId,
+				},
+				File:  f,
+				Crc64: true,
+			})
+			if err != nil {
+				t.Fatalf("upload error %v", err)
+			}
+
+			if uploaded, _ := server.Content(file.FileId); rapid || !bytes.Equal(uploaded, content) {
+				t.Errorf("expect normal upload, got rapid: %v, size: %d", rapid, len(uploaded))
+			}
+		})
+	}
+}
@@ -1,23 +1,173 @@
 package http
 
-import "github.com/go-resty/resty/v2"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/time/rate"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries 默认的最大重试次数
+	DefaultMaxRetries = 3
+	// DefaultRetryWaitMin 默认的首次重试等待时间
+	DefaultRetryWaitMin = 200 * time.Millisecond
+	// DefaultRetryWaitMax 默认的最长重试等待时间，Retry-After 同样不超过该值
+	DefaultRetryWaitMax = 10 * time.Second
+)
 
 type Client struct {
 	client *resty.Client
+
+	limiter      *rate.Limiter
+	semaphore    chan struct{}
+	maxRetries   int
+	retryWaitMin time.Duration
+	retryWaitMax time.Duration
+}
+
+// RetryOptions 请求失败时的重试配置，网络错误、429、503 和服务端限流错误码会按指数退避重试
+type RetryOptions struct {
+	MaxRetries int           // 最大重试次数，0 使用 DefaultMaxRetries，小于 0 不重试
+	WaitMin    time.Duration // 首次重试等待时间，之后每次翻倍，0 使用 DefaultRetryWaitMin
+	WaitMax    time.Duration // 最长等待时间，包括 Retry-After，0 使用 DefaultRetryWaitMax
 }
 
 func NewClient() *Client {
-	client := resty.New()
+	return &Client{
+		client:       resty.New(),
+		maxRetries:   DefaultMaxRetries,
+		retryWaitMin: DefaultRetryWaitMin,
+		retryWaitMax: DefaultRetryWaitMax,
+	}
+}
 
-	client.SetRetryCount(3)
+// SetTransport 设置底层 HTTP Transport
+func (c *Client) SetTransport(transport http.RoundTripper) *Client {
+	c.client.SetTransport(transport)
 
-	return &Client{
-		client: client,
+	return c
+}
+
+// WrapTransport 使用 wrap 包装当前的 HTTP Transport，可用于增加拦截器
+func (c *Client) WrapTransport(wrap func(transport http.RoundTripper) http.RoundTripper) *Client {
+	c.client.SetTransport(wrap(c.client.GetClient().Transport))
+
+	return c
+}
+
+// SetRateLimit 限制每秒发送的请求数，重试的请求同样计数，qps 不大于 0 时不限制
+func (c *Client) SetRateLimit(qps float64, burst int) *Client {
+	if qps <= 0 {
+		c.limiter = nil
+		return c
+	}
+
+	if burst <= 0 {
+		burst = 1
+	}
+
+	c.limiter = rate.NewLimiter(rate.Limit(qps), burst)
+
+	return c
+}
+
+// SetMaxConcurrency 限制同时进行的请求数，等待重试时不占用，n 不大于 0 时不限制
+func (c *Client) SetMaxConcurrency(n int) *Client {
+	if n <= 0 {
+		c.semaphore = nil
+		return c
 	}
+
+	c.semaphore = make(chan struct{}, n)
+
+	return c
+}
+
+// SetRetry 设置失败重试
+func (c *Client) SetRetry(options *RetryOptions) *Client {
+	c.maxRetries = options.MaxRetries
+	if c.maxRetries == 0 {
+		c.maxRetries = DefaultMaxRetries
+	} else if c.maxRetries < 0 {
+		c.maxRetries = 0
+	}
+
+	c.retryWaitMin = options.WaitMin
+	if c.retryWaitMin <= 0 {
+		c.retryWaitMin = DefaultRetryWaitMin
+	}
+
+	c.retryWaitMax = options.WaitMax
+	if c.retryWaitMax <= 0 {
+		c.retryWaitMax = DefaultRetryWaitMax
+	}
+
+	return c
 }
 
 func (c *Client) Send(request Request, response Response) error {
+	return c.SendWithContext(context.Background(), request, response)
+}
+
+// SendWithContext 发送请求，通过 ctx 控制取消和超时，失败时按 RetryOptions 重试
+func (c *Client) SendWithContext(ctx context.Context, request Request, response Response) error {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.execute(ctx, request)
+
+		if attempt >= c.maxRetries || ctx.Err() != nil || !shouldRetry(resp, err) {
+			if err != nil {
+				return err
+			}
+
+			return parseFromHTTPResponse(resp, response)
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(resp); ok {
+			wait = retryAfter
+		}
+
+		// Retry-After 过大时不能无限等待，没有 ctx 的请求无法取消
+		if wait > c.retryWaitMax {
+			wait = c.retryWaitMax
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// execute 在 QPS 和并发限制内发送一次请求
+func (c *Client) execute(ctx context.Context, request Request) (*resty.Response, error) {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.semaphore != nil {
+		select {
+		case c.semaphore <- struct{}{}:
+			defer func() { <-c.semaphore }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
 	r := c.client.R().
+		SetContext(ctx).
 		SetHeaders(map[string]string{
 			"content-type":    "application/json",
 			"user-agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/87.0.4280.88 Safari/537.36",
@@ -30,11 +180,64 @@ func (c *Client) Send(request Request, response Response) error {
 		SetQueryParams(request.GetQueryParams()).
 		SetBody(request)
 
-	resp, err := r.Execute(string(request.GetHttpMethod()), request.GetUrl())
+	return r.Execute(string(request.GetHttpMethod()), request.GetUrl())
+}
+
+// backoff 返回第 attempt 次重试前的等待时间，在指数退避的基础上加入随机抖动，不小于 retryWaitMin
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.retryWaitMax
+
+	// 比较时移位 retryWaitMax 而不是 retryWaitMin，避免左移溢出
+	if attempt < 63 && c.retryWaitMin <= c.retryWaitMax>>uint(attempt) {
+		wait = c.retryWaitMin << uint(attempt)
+	}
+
+	wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+
+	if wait < c.retryWaitMin {
+		wait = c.retryWaitMin
+	}
+
+	return wait
+}
 
+// shouldRetry 判断是否需要重试：网络错误、429、503 或服务端返回限流错误码
+func shouldRetry(resp *resty.Response, err error) bool {
 	if err != nil {
-		return err
+		var interceptorErr *InterceptorError
+
+		return !errors.As(err, &interceptorErr)
+	}
+
+	var base BaseResponse
+
+	_ = json.Unmarshal(resp.Body(), &base)
+
+	return IsRetryable(resp.StatusCode(), base.Code)
+}
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数和 HTTP 日期
+func parseRetryAfter(resp *resty.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header().Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+
+		return 0, true
 	}
 
-	return parseFromHTTPResponse(resp, response)
+	return 0, false
 }
@@ -1,19 +1,84 @@
 package http
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 常见服务端错误，AliyunDriveError 可以通过 errors.Is 判断
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrAlreadyExists  = errors.New("already exists")
+	ErrQuotaExhausted = errors.New("quota exhausted")
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrThrottled      = errors.New("throttled")
+	ErrForbidden      = errors.New("forbidden")
+)
+
+// codeErrors 错误码前缀对应的错误
+var codeErrors = []struct {
+	prefix string
+	err    error
+}{
+	{"NotFound", ErrNotFound},
+	{"AlreadyExist", ErrAlreadyExists},
+	{"QuotaExhausted", ErrQuotaExhausted},
+	{"AccessTokenInvalid", ErrInvalidToken},
+	{"AccessTokenExpired", ErrInvalidToken},
+	{"InvalidParameter.RefreshToken", ErrInvalidToken},
+	{"ShareLinkTokenInvalid", ErrInvalidToken},
+	{"TooManyRequests", ErrThrottled},
+	{"Throttling", ErrThrottled},
+	{"Forbidden", ErrForbidden},
+}
+
+// statusErrors 错误码未知时 HTTP 状态码对应的错误
+var statusErrors = map[int]error{
+	http.StatusNotFound:        ErrNotFound,
+	http.StatusUnauthorized:    ErrInvalidToken,
+	http.StatusForbidden:       ErrForbidden,
+	http.StatusTooManyRequests: ErrThrottled,
+}
 
 type AliyunDriveError struct {
-	Code    string
-	Message string
+	Code       string
+	Message    string
+	StatusCode int    // HTTP 状态码，批量操作中为子请求的状态码
+	RequestId  string // 服务端返回的请求 ID，用于排查问题
+	Retryable  bool   // 是否为限流或服务端暂时不可用等可以重试的错误
 }
 
 func (p *AliyunDriveError) Error() string {
 	return fmt.Sprintf("[AliyunDriveError] Code=%s, Message=%s", p.Code, p.Message)
 }
 
+// Is 支持 errors.Is 判断错误码对应的 ErrNotFound 等错误
+func (p *AliyunDriveError) Is(target error) bool {
+	return target != nil && errorOf(p.StatusCode, p.Code) == target
+}
+
 func NewAliyunDriveError(code, message string) error {
 	return &AliyunDriveError{
 		Code:    code,
 		Message: message,
 	}
 }
+
+// errorOf 返回错误码对应的错误，错误码未知时按 HTTP 状态码判断
+func errorOf(status int, code string) error {
+	for _, c := range codeErrors {
+		if strings.HasPrefix(code, c.prefix) {
+			return c.err
+		}
+	}
+
+	return statusErrors[status]
+}
+
+// IsRetryable 判断状态码和错误码是否表示可以重试：429、503 或服务端限流错误码
+func IsRetryable(status int, code string) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable ||
+		errorOf(0, code) == ErrThrottled
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Interceptor 请求拦截器，可用于添加追踪头、审计日志、请求签名等
+type Interceptor struct {
+	// BeforeRequest 发送请求前调用，可以修改 request，返回错误时中止请求且不会重试
+	BeforeRequest func(request *http.Request) error
+	// AfterResponse 收到响应或请求失败后调用，elapsed 为请求耗时，response 的 Body 仍由调用方读取和关闭
+	AfterResponse func(request *http.Request, response *http.Response, err error, elapsed time.Duration)
+}
+
+// InterceptorError BeforeRequest 返回的错误
+type InterceptorError struct {
+	Err error
+}
+
+func (e *InterceptorError) Error() string {
+	return fmt.Sprintf("[InterceptorError] %s", e.Err)
+}
+
+func (e *InterceptorError) Unwrap() error {
+	return e.Err
+}
+
+type interceptTransport struct {
+	base         http.RoundTripper
+	interceptors []*Interceptor
+}
+
+// NewInterceptTransport 返回依次经过 interceptors 的 RoundTripper，BeforeRequest 按顺序调用，AfterResponse 按相反顺序调用
+func NewInterceptTransport(base http.RoundTripper, interceptors []*Interceptor) http.RoundTripper {
+	if len(interceptors) == 0 {
+		return base
+	}
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &interceptTransport{
+		base:         base,
+		interceptors: interceptors,
+	}
+}
+
+func (t *interceptTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// RoundTripper 不能修改原请求，拦截器修改的是副本
+	request = request.Clone(request.Context())
+
+	called := 0
+
+	var response *http.Response
+	var err error
+
+	for _, interceptor := range t.interceptors {
+		called++
+
+		if interceptor.BeforeRequest != nil {
+			if err = interceptor.BeforeRequest(request); err != nil {
+				err = &InterceptorError{Err: err}
+				break
+			}
+		}
+	}
+
+	start := time.Now()
+
+	if err == nil {
+		response, err = t.base.RoundTrip(request)
+	}
+
+	elapsed := time.Since(start)
+
+	for i := called - 1; i >= 0; i-- {
+		if after := t.interceptors[i].AfterResponse; after != nil {
+			after(request, response, err, elapsed)
+		}
+	}
+
+	return response, err
+}
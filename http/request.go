@@ -17,9 +17,11 @@ type Request interface {
 	GetUrl() string
 	GetQueryParams() map[string]string
 	GetHeaders() map[string]string
+	GetEndpoint() string
 
 	SetHttpMethod(method Method) Request
 	SetUrl(url string) Request
+	SetEndpoint(endpoint string) Request
 }
 
 type BaseRequest struct {
@@ -55,6 +57,16 @@ func (receiver *BaseRequest) GetHeaders() map[string]string {
 	return receiver.headers
 }
 
+func (receiver *BaseRequest) GetEndpoint() string {
+	return receiver.endpoint
+}
+
+func (receiver *BaseRequest) SetEndpoint(endpoint string) Request {
+	receiver.endpoint = endpoint
+
+	return receiver
+}
+
 func (receiver *BaseRequest) SetUrl(url string) Request {
 	receiver.url = url
 
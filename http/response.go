@@ -36,14 +36,31 @@ func parseFromHTTPResponse(response *resty.Response, out Response) error {
 	if err != nil {
 		msg := fmt.Sprintf("Fail to parse json content: %s, because: %s", body, err)
 
-		return NewAliyunDriveError("ClientError.ParseJsonError", msg)
+		err = NewAliyunDriveError("ClientError.ParseJsonError", msg)
+	} else {
+		err = out.ParseErrorFromHTTPResponse(body)
 	}
 
-	err = out.ParseErrorFromHTTPResponse(body)
+	if driveErr, ok := err.(*AliyunDriveError); ok {
+		driveErr.StatusCode = response.StatusCode()
+		driveErr.RequestId = requestId(response, body)
+		driveErr.Retryable = IsRetryable(driveErr.StatusCode, driveErr.Code)
+	}
 
-	if err != nil {
-		return err
+	return err
+}
+
+// requestId 从响应头或错误响应的 requestId 字段获取请求 ID
+func requestId(response *resty.Response, body []byte) string {
+	if id := response.Header().Get("x-ca-request-id"); id != "" {
+		return id
 	}
 
-	return nil
+	var value struct {
+		RequestId string `json:"requestId"`
+	}
+
+	_ = json.Unmarshal(body, &value)
+
+	return value.RequestId
 }
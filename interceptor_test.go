@@ -0,0 +1,124 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"errors"
+	"github.com/jakeslee/aliyundrive/http"
+	"io"
+	gohttp "net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAliyunDrive_Interceptors(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	var traced []string
+
+	abort := errors.New("abort")
+	aborting := false
+
+	drive, cred, server := newTestClientWithOptions(t, &Options{
+		Interceptors: []*http.Interceptor{
+			{
+				BeforeRequest: func(request *gohttp.Request) error {
+					mu.Lock()
+					defer mu.Unlock()
+
+					order = append(order, "before 1")
+					request.Header.Set("x-trace-id", "trace-1")
+
+					if aborting {
+						return abort
+					}
+
+					return nil
+				},
+				AfterResponse: func(request *gohttp.Request, response *gohttp.Response, err error, elapsed time.Duration) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					order = append(order, "after 1")
+
+					if err == nil && elapsed > 0 && response.Request.Header.Get("x-trace-id") == "trace-1" {
+						traced = append(traced, request.URL.Path)
+					}
+				},
+			},
+			{
+				BeforeRequest: func(request *gohttp.Request) error {
+					mu.Lock()
+					defer mu.Unlock()
+
+					order = append(order, "before 2")
+
+					return nil
+				},
+				AfterResponse: func(request *gohttp.Request, response *gohttp.Response, err error, elapsed time.Duration) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					order = append(order, "after 2")
+				},
+			},
+		},
+	})
+
+	if strings.Join(order[:4], ",") != "before 1,before 2,after 2,after 1" {
+		t.Errorf("unexpected interceptor order %v", order[:4])
+	}
+
+	content := []byte("intercepted")
+
+	if _, err := drive.UploadFile(cred, &UploadFileOptions{
+		Name:         "intercepted.txt",
+		Size:         int64(len(content)),
+		ParentFileId: DefaultRootFileId,
+		Reader:       bytes.NewReader(content),
+	}); err != nil {
+		t.Fatalf("upload file error %v", err)
+	}
+
+	file := server.Children(DefaultRootFileId)[0]
+
+	response, err := drive.Download(cred, file.FileId, "")
+	if err != nil {
+		t.Fatalf("download error %v", err)
+	}
+
+	data, _ := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+
+	if !bytes.Equal(data, content) {
+		t.Errorf("unexpected downloaded content %q", data)
+	}
+
+	var upload, download bool
+
+	for _, path := range traced {
+		upload = upload || strings.HasPrefix(path, "/upload/")
+		download = download || strings.HasPrefix(path, "/download/")
+	}
+
+	if !upload || !download {
+		t.Errorf("raw upload and download requests should be intercepted, got %v", traced)
+	}
+
+	mu.Lock()
+	aborting = true
+	mu.Unlock()
+
+	count := server.RequestCount()
+
+	drive.EvictCacheWithPrefix("")
+
+	if _, err := drive.GetFile(cred, file.FileId); !errors.Is(err, abort) {
+		t.Errorf("request should be aborted by interceptor, got %v", err)
+	}
+
+	if server.RequestCount() != count {
+		t.Errorf("aborted request should not be sent or retried")
+	}
+}
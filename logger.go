@@ -0,0 +1,67 @@
+package aliyundrive
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+// Logger 日志接口，keysAndValues 为交替的字段名和值，如 "file_id", fileId
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+type logrusLogger struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogrusLogger 使用 logrus 输出日志，字段通过 WithFields 传递
+func NewLogrusLogger(logger logrus.FieldLogger) Logger {
+	return &logrusLogger{logger: logger}
+}
+
+func (l *logrusLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.logger.WithFields(logrusFields(keysAndValues)).Debug(msg)
+}
+
+func (l *logrusLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.logger.WithFields(logrusFields(keysAndValues)).Info(msg)
+}
+
+func (l *logrusLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.logger.WithFields(logrusFields(keysAndValues)).Warn(msg)
+}
+
+func (l *logrusLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.logger.WithFields(logrusFields(keysAndValues)).Error(msg)
+}
+
+// logrusFields 将交替的字段名和值转换为 logrus.Fields，落单的值使用 !BADKEY 作为字段名
+func logrusFields(keysAndValues []interface{}) logrus.Fields {
+	fields := make(logrus.Fields, len(keysAndValues)/2)
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			fields["!BADKEY"] = keysAndValues[i]
+			break
+		}
+
+		fields[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+	}
+
+	return fields
+}
+
+type nopLogger struct{}
+
+// NewNopLogger 返回丢弃所有日志的 Logger
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
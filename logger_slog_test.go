@@ -0,0 +1,30 @@
+//go:build go1.21
+// +build go1.21
+
+package aliyundrive
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestNewSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	NewSlogLogger(l).Debug("download file response", "file_id", "f1", "status", 206)
+
+	var entry map[string]interface{}
+
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("parse log entry error %v", err)
+	}
+
+	if entry["msg"] != "download file response" || entry["level"] != "DEBUG" || entry["file_id"] != "f1" ||
+		entry["status"] != float64(206) {
+		t.Errorf("unexpected log entry %v", entry)
+	}
+}
@@ -0,0 +1,99 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"testing"
+)
+
+type recordLogger struct {
+	mu      sync.Mutex
+	records []string
+}
+
+func (l *recordLogger) record(level, msg string, keysAndValues []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records = append(l.records, fmt.Sprintf("%s %s %v", level, msg, keysAndValues))
+}
+
+func (l *recordLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.record("debug", msg, keysAndValues)
+}
+
+func (l *recordLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.record("info", msg, keysAndValues)
+}
+
+func (l *recordLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.record("warn", msg, keysAndValues)
+}
+
+func (l *recordLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.record("error", msg, keysAndValues)
+}
+
+func (l *recordLogger) contains(record string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range l.records {
+		if r == record {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestAliyunDrive_Logger(t *testing.T) {
+	logger := &recordLogger{}
+
+	drive, cred, server := newTestClientWithOptions(t, &Options{
+		Logger:     logger,
+		UploadRate: 1024 * 1024,
+	})
+
+	file := server.AddFile(DefaultRootFileId, "log.txt", []byte("log"))
+
+	response, err := drive.Download(cred, file.FileId, "")
+	if err != nil {
+		t.Fatalf("download error %v", err)
+	}
+	_ = response.Body.Close()
+
+	for _, record := range []string{
+		"info speed limit [upload_rate 1048576 download_rate 0 credential_download_rate 0]",
+		fmt.Sprintf("debug token refreshed [user_id %s name %s]", cred.UserId, cred.Name),
+		fmt.Sprintf("debug download file response [file_id %s status 200]", file.FileId),
+	} {
+		if !logger.contains(record) {
+			t.Errorf("missing log %q in %q", record, logger.records)
+		}
+	}
+}
+
+func TestNewLogrusLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := logrus.New()
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+
+	NewLogrusLogger(l).Warn("upload part error", "file_id", "f1", "retry", 2, "dangling")
+
+	var entry map[string]interface{}
+
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("parse log entry error %v", err)
+	}
+
+	if entry["msg"] != "upload part error" || entry["level"] != "warning" || entry["file_id"] != "f1" ||
+		entry["retry"] != float64(2) || entry["!BADKEY"] != "dangling" {
+		t.Errorf("unexpected log entry %v", entry)
+	}
+}
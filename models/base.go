@@ -10,6 +10,7 @@ const (
 	AliyunDriveAuthEndpoint = "https://auth.aliyundrive.com"
 	CodeAccessTokenInvalid  = "AccessTokenInvalid"
 	CodePreHashMatched      = "PreHashMatched"
+	CodeInvalidProofCode    = "InvalidParameter.ProofCode"
 )
 
 type RefreshTokenRequest struct {
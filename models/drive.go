@@ -0,0 +1,44 @@
+package models
+
+import "github.com/jakeslee/aliyundrive/http"
+
+// Drive 用户的网盘空间，如默认 Drive、保险箱
+type Drive struct {
+	DriveId     string `json:"drive_id"`
+	DriveName   string `json:"drive_name"`
+	DriveType   string `json:"drive_type"`
+	Category    string `json:"category"` // 分类，保险箱为 sbox
+	Owner       string `json:"owner"`
+	OwnerType   string `json:"owner_type"`
+	Status      string `json:"status"`
+	TotalSize   int64  `json:"total_size"`
+	UsedSize    int64  `json:"used_size"`
+	Description string `json:"description"`
+}
+
+type ListDrivesRequest struct {
+	http.BaseRequest
+
+	Limit  int    `json:"limit"`
+	Marker string `json:"marker"`
+}
+
+type ListDrivesResponse struct {
+	http.BaseResponse
+
+	Items      []*Drive `json:"items"`
+	NextMarker string   `json:"next_marker"`
+}
+
+// NewListDrivesRequest 创建获取当前用户所有 Drive 请求
+func NewListDrivesRequest() *ListDrivesRequest {
+	r := &ListDrivesRequest{
+		Limit: 100,
+	}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v2/drive/list_my_drives")
+
+	return r
+}
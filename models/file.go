@@ -1,7 +1,9 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/jakeslee/aliyundrive/http"
 	http2 "net/http"
 	"time"
@@ -175,38 +177,96 @@ type GetUploadUrlRequest struct {
 	http.BaseRequest
 
 	DriveId      string      `json:"drive_id"`
-	FileId       string      `json:"file_id"`        // 文件 ID
-	ParentFileId string      `json:"parent_file_id"` // 父文件 ID
-	Type         string      `json:"type"`
-	Name         string      `json:"name"`           // 文件名字
-	ContentType  string      `json:"content_type"`   // 内容类型
-	PartInfoList []*PartInfo `json:"part_info_list"` // 文件上传分片信息
+	FileId       string      `json:"file_id"`                  // 文件 ID
+	UploadId     string      `json:"upload_id"`                // 上传 ID
+	ParentFileId string      `json:"parent_file_id,omitempty"` // 父文件 ID
+	Type         string      `json:"type,omitempty"`
+	Name         string      `json:"name,omitempty"`         // 文件名字
+	ContentType  string      `json:"content_type,omitempty"` // 内容类型
+	PartInfoList []*PartInfo `json:"part_info_list"`         // 文件上传分片信息，只需要 PartNumber
 }
 
 type GetUploadUrlResponse struct {
 	http.BaseResponse
 
+	DomainId     string      `json:"domain_id"`
+	DriveId      string      `json:"drive_id"`
+	FileId       string      `json:"file_id"`
+	UploadId     string      `json:"upload_id"`
 	PartInfoList []*PartInfo `json:"part_info_list"` // 文件上传分片信息
 }
 
+// NewGetUploadUrlRequest 创建重新获取分片上传地址请求，上传地址过期或断点续传时使用
+func NewGetUploadUrlRequest() *GetUploadUrlRequest {
+	r := &GetUploadUrlRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v2/file/get_upload_url")
+
+	return r
+}
+
+type ListUploadedPartsRequest struct {
+	http.BaseRequest
+
+	DriveId          string `json:"drive_id"`
+	FileId           string `json:"file_id"`
+	UploadId         string `json:"upload_id"`
+	PartNumberMarker int    `json:"part_number_marker,omitempty"` // 分页标记
+}
+
+type ListUploadedPartsResponse struct {
+	http.BaseResponse
+
+	FileId               string      `json:"file_id"`
+	UploadId             string      `json:"upload_id"`
+	ParallelUpload       bool        `json:"parallel_upload"`
+	UploadedParts        []*PartInfo `json:"uploaded_parts"`
+	NextPartNumberMarker string      `json:"next_part_number_marker"`
+}
+
+// NewListUploadedPartsRequest 创建获取已上传分片请求
+func NewListUploadedPartsRequest() *ListUploadedPartsRequest {
+	r := &ListUploadedPartsRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v2/file/list_uploaded_parts")
+
+	return r
+}
+
 type PartInfo struct {
 	PartSize          int64   `json:"part_size"`           // 分片大小
-	PartNumber        int8    `json:"part_number"`         // 分片序号
+	PartNumber        int     `json:"part_number"`         // 分片序号，从 1 开始，最大为 MaxPartCount
 	ContentType       string  `json:"content_type"`        // 内容类型
 	InternalUploadUrl *string `json:"internal_upload_url"` // 内部上传地址
 	UploadUrl         *string `json:"upload_url"`          // 分片上传路径
+	Etag              string  `json:"etag,omitempty"`      // 已上传分片的 ETag
 	Id                int     // 分片 ID
 	StartOffset       int64   // 当前分片开始位置
 	EndOffset         int64   // 分片结束位置
 	IsUploaded        bool    // 是否已经上传
 }
 
-// NewPartInfoList 基于文件大小和分片大小创建分片信息
+// MaxPartCount 单个文件最多的分片数量
+const MaxPartCount = 10000
+
+// NewPartInfoList 基于文件大小和分片大小创建分片信息，分片数量不能超过 MaxPartCount
 func NewPartInfoList(size, partSize int64) ([]*PartInfo, error) {
 	if size < 0 {
 		return nil, errors.New("size cannot be -1")
 	}
 
+	if partSize <= 0 {
+		return nil, errors.New("part size must be positive")
+	}
+
+	if (size+partSize-1)/partSize > MaxPartCount {
+		return nil, fmt.Errorf("part count exceeds %d, size %d, part size %d", MaxPartCount, size, partSize)
+	}
+
 	var result []*PartInfo
 
 	if size == 0 {
@@ -220,7 +280,7 @@ func NewPartInfoList(size, partSize int64) ([]*PartInfo, error) {
 			Id:          len(result),
 			StartOffset: count,
 			EndOffset:   count + partSize - 1,
-			PartNumber:  int8(len(result) + 1),
+			PartNumber:  len(result) + 1,
 			PartSize:    partSize,
 		})
 
@@ -237,7 +297,7 @@ func NewPartInfoList(size, partSize int64) ([]*PartInfo, error) {
 		Id:          len(result),
 		StartOffset: count,
 		EndOffset:   endOffset,
-		PartNumber:  int8(len(result) + 1),
+		PartNumber:  len(result) + 1,
 		PartSize:    partSize,
 	})
 
@@ -408,6 +468,74 @@ func NewMoveFileRequest() *MoveFileRequest {
 	return r
 }
 
+type CopyFileRequest struct {
+	http.BaseRequest
+
+	DriveId        string `json:"drive_id"`
+	FileId         string `json:"file_id"`
+	ToDriveId      string `json:"to_drive_id"`
+	ToParentFileId string `json:"to_parent_file_id"`
+	NewName        string `json:"new_name,omitempty"`
+	AutoRename     bool   `json:"auto_rename"`
+}
+
+type CopyFileResponse struct {
+	http.BaseResponse
+
+	DomainId    string `json:"domain_id"`
+	DriveId     string `json:"drive_id"`
+	FileId      string `json:"file_id"`
+	AsyncTaskId string `json:"async_task_id"` // 复制目录时返回异步任务 ID
+}
+
+// NewCopyFileRequest 创建复制文件请求
+func NewCopyFileRequest() *CopyFileRequest {
+	r := &CopyFileRequest{
+		AutoRename: true,
+	}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v2/file/copy")
+
+	return r
+}
+
+type AsyncTaskRequest struct {
+	http.BaseRequest
+
+	AsyncTaskId string `json:"async_task_id"`
+}
+
+type AsyncTaskState string
+
+const (
+	AsyncTaskStateRunning AsyncTaskState = "Running"
+	AsyncTaskStateSucceed AsyncTaskState = "Succeed"
+	AsyncTaskStateFailed  AsyncTaskState = "Failed"
+)
+
+type AsyncTaskResponse struct {
+	http.BaseResponse
+
+	AsyncTaskId     string         `json:"async_task_id"`
+	State           AsyncTaskState `json:"state"`
+	ErrCode         string         `json:"err_code"`
+	TotalProcess    int64          `json:"total_process"`    // 总处理数量
+	ConsumedProcess int64          `json:"consumed_process"` // 已处理数量
+}
+
+// NewAsyncTaskRequest 创建查询异步任务请求
+func NewAsyncTaskRequest() *AsyncTaskRequest {
+	r := &AsyncTaskRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v2/async_task/get")
+
+	return r
+}
+
 type BatchRequest struct {
 	http.BaseRequest
 
@@ -426,13 +554,19 @@ type BatchRequestItem struct {
 type BatchResponse struct {
 	http.BaseResponse
 
-	Responses []*struct {
-		Body   map[string]interface{} `json:"body"`
-		Id     string                 `json:"id"`
-		Status int                    `json:"status"`
-	} `json:"responses"`
+	Responses []*BatchResponseItem `json:"responses"`
 }
 
+// BatchResponseItem 批处理子请求的响应，Body 根据子请求类型解析
+type BatchResponseItem struct {
+	Body   json.RawMessage `json:"body"`
+	Id     string          `json:"id"`
+	Status int             `json:"status"`
+}
+
+// BatchRequestsLimit 单次批处理请求最多包含的子请求数量
+const BatchRequestsLimit = 100
+
 // NewBatchRequest 创建批处理操作请求
 func NewBatchRequest() *BatchRequest {
 	r := &BatchRequest{
@@ -446,20 +580,71 @@ func NewBatchRequest() *BatchRequest {
 	return r
 }
 
+// Add 增加批处理子请求
+func (r *BatchRequest) Add(id, url string, body http.Request) *BatchRequest {
+	r.Requests = append(r.Requests, &BatchRequestItem{
+		Id:     id,
+		Method: http2.MethodPost,
+		Url:    url,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: body,
+	})
+
+	return r
+}
+
 // NewBatchMoveRequest 创建批量移动文件请求
 func NewBatchMoveRequest(requests []*MoveFileRequest) *BatchRequest {
 	batchRequest := NewBatchRequest()
 
 	for _, request := range requests {
-		batchRequest.Requests = append(batchRequest.Requests, &BatchRequestItem{
-			Id:     request.FileId,
-			Method: http2.MethodPost,
-			Url:    "/file/move",
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-			Body: request,
-		})
+		batchRequest.Add(request.FileId, "/file/move", request)
+	}
+
+	return batchRequest
+}
+
+// NewBatchGetRequest 创建批量获取文件信息请求
+func NewBatchGetRequest(requests []*FileRequest) *BatchRequest {
+	batchRequest := NewBatchRequest()
+
+	for _, request := range requests {
+		batchRequest.Add(request.FileId, "/file/get", request)
+	}
+
+	return batchRequest
+}
+
+// NewBatchRemoveRequest 创建批量删除文件请求
+func NewBatchRemoveRequest(requests []*RemoveFileRequest) *BatchRequest {
+	batchRequest := NewBatchRequest()
+
+	for _, request := range requests {
+		batchRequest.Add(request.FileId, "/recyclebin/trash", request)
+	}
+
+	return batchRequest
+}
+
+// NewBatchCopyRequest 创建批量复制文件请求
+func NewBatchCopyRequest(requests []*CopyFileRequest) *BatchRequest {
+	batchRequest := NewBatchRequest()
+
+	for _, request := range requests {
+		batchRequest.Add(request.FileId, "/file/copy", request)
+	}
+
+	return batchRequest
+}
+
+// NewBatchRenameRequest 创建批量重命名文件请求
+func NewBatchRenameRequest(requests []*RenameFileRequest) *BatchRequest {
+	batchRequest := NewBatchRequest()
+
+	for _, request := range requests {
+		batchRequest.Add(request.FileId, "/file/update", request)
 	}
 
 	return batchRequest
@@ -531,6 +716,7 @@ type File struct {
 	Hidden       bool      `json:"hidden"`
 	ParentFileId string    `json:"parent_file_id"`
 	Starred      bool      `json:"starred"`
+	Trashed      bool      `json:"trashed"`
 	Status       string    `json:"status"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
@@ -0,0 +1,92 @@
+package models
+
+import "github.com/jakeslee/aliyundrive/http"
+
+// RecycleBinListRequest 获取回收站文件列表
+type RecycleBinListRequest struct {
+	http.BaseRequest
+
+	DriveId               string `json:"drive_id"`
+	Limit                 int    `json:"limit"`           // 单次拉取数量
+	Marker                string `json:"marker"`          // 分页拉新标记
+	OrderBy               string `json:"order_by"`        // 排序字段
+	OrderDirection        string `json:"order_direction"` // 排序方向，DESC/ASC
+	ImageThumbnailProcess string `json:"image_thumbnail_process"`
+	VideoThumbnailProcess string `json:"video_thumbnail_process"`
+}
+
+type RecycleBinListResponse struct {
+	http.BaseResponse
+	Files
+}
+
+func NewRecycleBinListRequest() *RecycleBinListRequest {
+	r := &RecycleBinListRequest{
+		Limit:                 100,
+		OrderBy:               "name",
+		OrderDirection:        OrderDirectionTypeDescend,
+		ImageThumbnailProcess: ImageThumbnailProcessDefault,
+		VideoThumbnailProcess: VideoThumbnailProcessDefault,
+	}
+
+	r.Init(AliyunDriveEndpoint).SetHttpMethod(http.Post).SetUrl("/v2/recyclebin/list")
+
+	return r
+}
+
+// RecycleBinFileRequest 回收站中单个文件的操作，用于恢复和彻底删除
+type RecycleBinFileRequest struct {
+	http.BaseRequest
+
+	DriveId string `json:"drive_id"`
+	FileId  string `json:"file_id"`
+}
+
+// RecycleBinResponse 回收站操作结果，目录或清空回收站时返回异步任务 ID
+type RecycleBinResponse struct {
+	http.BaseResponse
+
+	DomainId    string `json:"domain_id"`
+	DriveId     string `json:"drive_id"`
+	FileId      string `json:"file_id"`
+	AsyncTaskId string `json:"async_task_id"`
+}
+
+// NewRestoreFileRequest 创建从回收站恢复文件请求
+func NewRestoreFileRequest() *RecycleBinFileRequest {
+	r := &RecycleBinFileRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v2/recyclebin/restore")
+
+	return r
+}
+
+// NewDeleteFileRequest 创建彻底删除文件请求，不经过回收站
+func NewDeleteFileRequest() *RecycleBinFileRequest {
+	r := &RecycleBinFileRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v3/file/delete")
+
+	return r
+}
+
+type ClearRecycleBinRequest struct {
+	http.BaseRequest
+
+	DriveId string `json:"drive_id"`
+}
+
+// NewClearRecycleBinRequest 创建清空回收站请求
+func NewClearRecycleBinRequest() *ClearRecycleBinRequest {
+	r := &ClearRecycleBinRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v2/recyclebin/clear")
+
+	return r
+}
@@ -0,0 +1,342 @@
+package models
+
+import (
+	"github.com/jakeslee/aliyundrive/http"
+	"time"
+)
+
+// ShareExpirationLayout 分享过期时间格式
+const ShareExpirationLayout = "2006-01-02T15:04:05.000Z"
+
+// ShareLink 分享链接
+type ShareLink struct {
+	ShareId       string    `json:"share_id"`
+	ShareName     string    `json:"share_name"`
+	ShareUrl      string    `json:"share_url"`
+	SharePwd      string    `json:"share_pwd"`   // 提取码，为空表示无需提取码
+	Expiration    string    `json:"expiration"`  // 过期时间，为空表示永久有效
+	Expired       bool      `json:"expired"`     // 是否已过期
+	Status        string    `json:"status"`      // 状态，enabled/disabled
+	Description   string    `json:"description"` // 描述
+	Creator       string    `json:"creator"`
+	DriveId       string    `json:"drive_id"`
+	FileIdList    []string  `json:"file_id_list"`
+	PreviewCount  int64     `json:"preview_count"`
+	SaveCount     int64     `json:"save_count"`
+	DownloadCount int64     `json:"download_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+const (
+	ShareStatusEnabled  = "enabled"
+	ShareStatusDisabled = "disabled"
+)
+
+// ExpireTime 解析过期时间，永久有效时返回 false
+func (s *ShareLink) ExpireTime() (time.Time, bool) {
+	if s.Expiration == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(ShareExpirationLayout, s.Expiration)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, s.Expiration)
+	}
+
+	return t, err == nil
+}
+
+// FormatShareExpiration 格式化分享过期时间，零值表示永久有效
+func FormatShareExpiration(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(ShareExpirationLayout)
+}
+
+type ShareLinkResponse struct {
+	http.BaseResponse
+	ShareLink
+}
+
+type CreateShareRequest struct {
+	http.BaseRequest
+
+	DriveId    string   `json:"drive_id"`
+	FileIdList []string `json:"file_id_list"`
+	ShareName  string   `json:"share_name,omitempty"`
+	SharePwd   string   `json:"share_pwd"`
+	Expiration string   `json:"expiration"`
+}
+
+// NewCreateShareRequest 创建分享链接请求
+func NewCreateShareRequest() *CreateShareRequest {
+	r := &CreateShareRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/adrive/v2/share_link/create")
+
+	return r
+}
+
+type ListSharesRequest struct {
+	http.BaseRequest
+
+	Creator          string `json:"creator"`
+	IncludeCancelled bool   `json:"include_cancelled"`
+	Limit            int    `json:"limit"`
+	Marker           string `json:"marker"`
+	OrderBy          string `json:"order_by"`
+	OrderDirection   string `json:"order_direction"`
+}
+
+type ListSharesResponse struct {
+	http.BaseResponse
+
+	Items      []*ShareLink `json:"items"`
+	NextMarker string       `json:"next_marker"`
+}
+
+// NewListSharesRequest 创建获取分享列表请求
+func NewListSharesRequest() *ListSharesRequest {
+	r := &ListSharesRequest{
+		Limit:          100,
+		OrderBy:        "created_at",
+		OrderDirection: OrderDirectionTypeDescend,
+	}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/adrive/v3/share_link/list")
+
+	return r
+}
+
+type UpdateShareRequest struct {
+	http.BaseRequest
+
+	ShareId    string `json:"share_id"`
+	ShareName  string `json:"share_name,omitempty"`
+	SharePwd   string `json:"share_pwd"`
+	Expiration string `json:"expiration"`
+}
+
+// NewUpdateShareRequest 创建修改分享请求
+func NewUpdateShareRequest() *UpdateShareRequest {
+	r := &UpdateShareRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/adrive/v2/share_link/update")
+
+	return r
+}
+
+type CancelShareRequest struct {
+	http.BaseRequest
+
+	ShareId string `json:"share_id"`
+}
+
+// NewCancelShareRequest 创建取消分享请求
+func NewCancelShareRequest() *CancelShareRequest {
+	r := &CancelShareRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/adrive/v2/share_link/cancel")
+
+	return r
+}
+
+// CodeShareLinkTokenInvalid share token 失效或与分享不匹配
+const CodeShareLinkTokenInvalid = "ShareLinkTokenInvalid"
+
+// WithShareToken 设置访问分享所需的 share token
+func WithShareToken(request http.Request, shareToken string) {
+	request.GetHeaders()["x-share-token"] = shareToken
+}
+
+type ShareTokenRequest struct {
+	http.BaseRequest
+
+	ShareId  string `json:"share_id"`
+	SharePwd string `json:"share_pwd"`
+}
+
+type ShareTokenResponse struct {
+	http.BaseResponse
+
+	ShareToken string    `json:"share_token"`
+	ExpireTime time.Time `json:"expire_time"`
+	ExpiresIn  int       `json:"expires_in"`
+}
+
+// NewShareTokenRequest 创建获取 share token 请求，无需登录
+func NewShareTokenRequest() *ShareTokenRequest {
+	r := &ShareTokenRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v2/share_link/get_share_token")
+
+	return r
+}
+
+type ShareInfoRequest struct {
+	http.BaseRequest
+
+	ShareId string `json:"share_id"`
+}
+
+type ShareFileInfo struct {
+	FileId   string   `json:"file_id"`
+	FileName string   `json:"file_name"`
+	Type     FileType `json:"type"`
+}
+
+type ShareInfoResponse struct {
+	http.BaseResponse
+
+	CreatorId   string           `json:"creator_id"`
+	CreatorName string           `json:"creator_name"`
+	ShareName   string           `json:"share_name"`
+	Expiration  string           `json:"expiration"`
+	FileCount   int              `json:"file_count"`
+	FileInfos   []*ShareFileInfo `json:"file_infos"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// NewShareInfoRequest 创建匿名获取分享信息请求，无需登录和 share token
+func NewShareInfoRequest() *ShareInfoRequest {
+	r := &ShareInfoRequest{}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/adrive/v3/share_link/get_share_by_anonymous")
+
+	return r
+}
+
+// ShareFilesRequest 获取分享中目录下的文件列表
+type ShareFilesRequest struct {
+	http.BaseRequest
+
+	ShareId               string `json:"share_id"`
+	ParentFileId          string `json:"parent_file_id"` // 目录 ID，root 表示分享的顶层文件
+	Limit                 int    `json:"limit"`
+	Marker                string `json:"marker"`
+	OrderBy               string `json:"order_by"`
+	OrderDirection        string `json:"order_direction"`
+	ImageThumbnailProcess string `json:"image_thumbnail_process"`
+	ImageUrlProcess       string `json:"image_url_process"`
+	VideoThumbnailProcess string `json:"video_thumbnail_process"`
+}
+
+type ShareFilesResponse struct {
+	http.BaseResponse
+	Files
+}
+
+func NewShareFilesRequest() *ShareFilesRequest {
+	r := &ShareFilesRequest{
+		Limit:                 100,
+		OrderBy:               "name",
+		OrderDirection:        OrderDirectionTypeAscend,
+		ImageThumbnailProcess: ImageThumbnailProcessDefault,
+		ImageUrlProcess:       ImageUrlProcessDefault,
+		VideoThumbnailProcess: VideoThumbnailProcessDefault,
+	}
+
+	r.Init(AliyunDriveEndpoint).SetHttpMethod(http.Post).SetUrl("/adrive/v2/file/list_by_share")
+
+	return r
+}
+
+type ShareFileRequest struct {
+	http.BaseRequest
+
+	ShareId               string `json:"share_id"`
+	FileId                string `json:"file_id"`
+	Fields                string `json:"fields"`
+	ImageThumbnailProcess string `json:"image_thumbnail_process"`
+	VideoThumbnailProcess string `json:"video_thumbnail_process"`
+}
+
+// NewShareFileRequest 创建获取分享中文件信息请求
+func NewShareFileRequest() *ShareFileRequest {
+	r := &ShareFileRequest{
+		Fields:                "*",
+		ImageThumbnailProcess: ImageThumbnailProcessDefault,
+		VideoThumbnailProcess: VideoThumbnailProcessDefault,
+	}
+
+	r.Init(AliyunDriveEndpoint).SetHttpMethod(http.Post).SetUrl("/adrive/v2/file/get_by_share")
+
+	return r
+}
+
+type ShareDownloadURLRequest struct {
+	http.BaseRequest
+
+	ShareId   string `json:"share_id"`
+	FileId    string `json:"file_id"`
+	ExpireSec int    `json:"expire_sec"`
+}
+
+type ShareDownloadURLResponse struct {
+	http.BaseResponse
+
+	DownloadUrl string    `json:"download_url"`
+	Url         string    `json:"url"`
+	Expiration  time.Time `json:"expiration"`
+}
+
+// NewShareDownloadURLRequest 创建获取分享文件下载地址请求，需要登录和 share token
+func NewShareDownloadURLRequest() *ShareDownloadURLRequest {
+	r := &ShareDownloadURLRequest{
+		ExpireSec: 600,
+	}
+
+	r.Init(AliyunDriveEndpoint).SetHttpMethod(http.Post).SetUrl("/v2/file/get_share_link_download_url")
+
+	return r
+}
+
+// SaveShareFileRequest 转存分享中的文件到自己的 Drive
+type SaveShareFileRequest struct {
+	http.BaseRequest
+
+	ShareId        string `json:"share_id"`
+	FileId         string `json:"file_id"`
+	ToDriveId      string `json:"to_drive_id"`
+	ToParentFileId string `json:"to_parent_file_id"`
+	AutoRename     bool   `json:"auto_rename"`
+}
+
+// NewSaveShareFileRequest 创建转存分享文件请求，需要登录和 share token
+func NewSaveShareFileRequest() *SaveShareFileRequest {
+	r := &SaveShareFileRequest{
+		AutoRename: true,
+	}
+
+	r.Init(AliyunDriveEndpoint).
+		SetHttpMethod(http.Post).
+		SetUrl("/v2/file/copy")
+
+	return r
+}
+
+// NewBatchSaveShareFileRequest 创建批量转存分享文件请求，share token 需要设置在批处理请求上
+func NewBatchSaveShareFileRequest(requests []*SaveShareFileRequest) *BatchRequest {
+	batchRequest := NewBatchRequest()
+
+	for _, request := range requests {
+		batchRequest.Add(request.FileId, "/file/copy", request)
+	}
+
+	return batchRequest
+}
@@ -0,0 +1,225 @@
+package aliyundrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jakeslee/aliyundrive/models"
+	"io"
+	http2 "net/http"
+	"os"
+	"sync"
+)
+
+// OpenFileReadAheadDefault 默认预读大小 1MB
+const OpenFileReadAheadDefault = 1024 * 1024
+
+type OpenFileOptions struct {
+	DriveId   string // 文件所在的 Drive，为空时使用 credential.DefaultDriveId
+	ReadAhead int    // 每次请求在所需数据之后额外读取的字节数，0 时使用 OpenFileReadAheadDefault，小于 0 时不预读
+}
+
+// RemoteFile 通过分段下载随机读取云盘文件，实现 io.ReadSeekCloser 和 io.ReaderAt，可以并发调用
+type RemoteFile struct {
+	ctx        context.Context
+	drive      *AliyunDrive
+	credential *Credential
+	driveId    string
+	file       *models.File
+	readAhead  int
+
+	mu        sync.Mutex
+	offset    int64
+	buf       []byte
+	bufOffset int64
+	closed    bool
+}
+
+var _ interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+} = (*RemoteFile)(nil)
+
+// OpenFile 打开云盘文件用于随机读取，数据通过 Download 分段下载，下载地址过期时自动重新获取
+func (d *AliyunDrive) OpenFile(credential *Credential, fileId string, options *OpenFileOptions) (*RemoteFile, error) {
+	return d.OpenFileWithContext(context.Background(), credential, fileId, options)
+}
+
+// OpenFileWithContext 同 OpenFile，通过 ctx 控制取消和超时，ctx 作用于返回文件的所有读取
+func (d *AliyunDrive) OpenFileWithContext(ctx context.Context, credential *Credential, fileId string,
+	options *OpenFileOptions) (*RemoteFile, error) {
+	if options == nil {
+		options = &OpenFileOptions{}
+	}
+
+	readAhead := options.ReadAhead
+	if readAhead == 0 {
+		readAhead = OpenFileReadAheadDefault
+	} else if readAhead < 0 {
+		readAhead = 0
+	}
+
+	fileResp, err := d.GetFileInDriveWithContext(ctx, credential, options.DriveId, fileId)
+	if err != nil {
+		return nil, err
+	}
+
+	if fileResp.Type == models.FileTypeFolder {
+		return nil, fmt.Errorf("file %s is a folder", fileId)
+	}
+
+	return &RemoteFile{
+		ctx:        ctx,
+		drive:      d,
+		credential: credential,
+		driveId:    options.DriveId,
+		file:       fileResp.File,
+		readAhead:  readAhead,
+	}, nil
+}
+
+// File 返回打开时获取的文件信息
+func (f *RemoteFile) File() *models.File {
+	return f.file
+}
+
+// Size 返回文件大小
+func (f *RemoteFile) Size() int64 {
+	return f.file.Size
+}
+
+func (f *RemoteFile) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Read 只需返回部分数据，缓冲区有数据时不再发起请求
+	if !f.buffered(f.offset) {
+		if f.offset >= f.file.Size {
+			return 0, io.EOF
+		}
+
+		if err := f.fill(f.offset, len(p)); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, f.buf[f.offset-f.bufOffset:])
+	f.offset += int64(n)
+
+	return n, nil
+}
+
+func (f *RemoteFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	n := 0
+
+	for n < len(p) {
+		pos := off + int64(n)
+
+		if pos >= f.file.Size {
+			return n, io.EOF
+		}
+
+		if !f.buffered(pos) {
+			if err := f.fill(pos, len(p)-n); err != nil {
+				return n, err
+			}
+		}
+
+		n += copy(p[n:], f.buf[pos-f.bufOffset:])
+	}
+
+	return n, nil
+}
+
+func (f *RemoteFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.file.Size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	f.offset = offset
+
+	return offset, nil
+}
+
+func (f *RemoteFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	f.closed = true
+	f.buf = nil
+
+	return nil
+}
+
+// buffered 判断 offset 处的数据是否在缓冲区中
+func (f *RemoteFile) buffered(offset int64) bool {
+	return offset >= f.bufOffset && offset < f.bufOffset+int64(len(f.buf))
+}
+
+// fill 从 offset 开始下载 size 字节及预读数据替换缓冲区
+func (f *RemoteFile) fill(offset int64, size int) error {
+	end := Min(offset+int64(size)+int64(f.readAhead), f.file.Size) - 1
+
+	response, err := f.drive.DownloadInDriveWithContext(f.ctx, f.credential, f.driveId, f.file.FileId, fmt.Sprintf("bytes=%d-%d", offset, end))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// 请求整个文件时服务端可能直接返回 200
+	if response.StatusCode != http2.StatusPartialContent &&
+		!(response.StatusCode == http2.StatusOK && offset == 0 && end == f.file.Size-1) {
+		return fmt.Errorf("download file %s range %d-%d error, status: %d", f.file.FileId, offset, end, response.StatusCode)
+	}
+
+	buf := make([]byte, end-offset+1)
+
+	if _, err := io.ReadFull(response.Body, buf); err != nil {
+		return err
+	}
+
+	f.buf = buf
+	f.bufOffset = offset
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestAliyunDrive_OpenFile(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	file := server.AddFile(DefaultRootFileId, "random.bin", content)
+
+	remote, err := drive.OpenFile(cred, file.FileId, &OpenFileOptions{ReadAhead: 1000})
+	if err != nil {
+		t.Fatalf("open file error %v", err)
+	}
+
+	head := make([]byte, 500)
+	if _, err := io.ReadFull(remote, head); err != nil || !bytes.Equal(head, content[:500]) {
+		t.Fatalf("unexpected head %q, %v", head[:10], err)
+	}
+
+	// 下载地址过期后继续读取，自动重新获取地址
+	server.ExpireDownloadUrls()
+
+	rest, err := io.ReadAll(remote)
+	if err != nil || !bytes.Equal(rest, content[500:]) {
+		t.Fatalf("read rest error %v, got %d bytes", err, len(rest))
+	}
+
+	if pos, err := remote.Seek(-15, io.SeekEnd); err != nil || pos != int64(len(content))-15 {
+		t.Fatalf("seek error %v, pos %d", err, pos)
+	}
+
+	tail := make([]byte, 20)
+	if n, err := remote.Read(tail); err != nil || n != 15 || !bytes.Equal(tail[:n], content[len(content)-15:]) {
+		t.Errorf("unexpected tail %q, %v", tail[:n], err)
+	}
+
+	if _, err := remote.Read(tail); err != io.EOF {
+		t.Errorf("read at end should return EOF, got %v", err)
+	}
+
+	part := make([]byte, 2500)
+	if n, err := remote.ReadAt(part, 3333); err != nil || !bytes.Equal(part[:n], content[3333:5833]) {
+		t.Errorf("unexpected ReadAt result, %d bytes, %v", n, err)
+	}
+
+	if n, err := remote.ReadAt(part, int64(len(content))-100); err != io.EOF || n != 100 {
+		t.Errorf("ReadAt past end should return EOF, got %d, %v", n, err)
+	}
+
+	if err := remote.Close(); err != nil {
+		t.Errorf("close error %v", err)
+	}
+
+	if _, err := remote.Read(part); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("read after close should fail, got %v", err)
+	}
+}
@@ -1,31 +1,64 @@
 package aliyundrive
 
-import "github.com/jakeslee/aliyundrive/models"
+import (
+	"context"
+	"github.com/jakeslee/aliyundrive/models"
+)
 
 // GetVideoPreviewUrl 获取视频预览 URL
 func (d *AliyunDrive) GetVideoPreviewUrl(credential *Credential, fileId string) (*models.VideoPreviewUrlResponse, error) {
+	return d.GetVideoPreviewUrlWithContext(context.Background(), credential, fileId)
+}
+
+// GetVideoPreviewUrlWithContext 同 GetVideoPreviewUrl，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetVideoPreviewUrlWithContext(ctx context.Context, credential *Credential, fileId string) (*models.VideoPreviewUrlResponse, error) {
+	return d.GetVideoPreviewUrlInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// GetVideoPreviewUrlInDrive 同 GetVideoPreviewUrl，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) GetVideoPreviewUrlInDrive(credential *Credential, driveId, fileId string) (*models.VideoPreviewUrlResponse, error) {
+	return d.GetVideoPreviewUrlInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// GetVideoPreviewUrlInDriveWithContext 同 GetVideoPreviewUrlInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetVideoPreviewUrlInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) (*models.VideoPreviewUrlResponse, error) {
 	request := models.NewVideoPreviewUrlRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 	request.FileId = fileId
 
 	var resp models.VideoPreviewUrlResponse
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	return &resp, err
 }
 
 // GetVideoPreviewPlayInfo 获取视频播放信息
 func (d *AliyunDrive) GetVideoPreviewPlayInfo(credential *Credential, fileId string) (*models.VideoPreviewPlayInfoResponse, error) {
+	return d.GetVideoPreviewPlayInfoWithContext(context.Background(), credential, fileId)
+}
+
+// GetVideoPreviewPlayInfoWithContext 同 GetVideoPreviewPlayInfo，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetVideoPreviewPlayInfoWithContext(ctx context.Context, credential *Credential, fileId string) (*models.VideoPreviewPlayInfoResponse, error) {
+	return d.GetVideoPreviewPlayInfoInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// GetVideoPreviewPlayInfoInDrive 同 GetVideoPreviewPlayInfo，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) GetVideoPreviewPlayInfoInDrive(credential *Credential, driveId, fileId string) (*models.VideoPreviewPlayInfoResponse, error) {
+	return d.GetVideoPreviewPlayInfoInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// GetVideoPreviewPlayInfoInDriveWithContext 同 GetVideoPreviewPlayInfoInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetVideoPreviewPlayInfoInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) (*models.VideoPreviewPlayInfoResponse, error) {
 	request := models.NewVideoPreviewPlayInfoRequest()
 
-	request.DriveId = credential.DefaultDriveId
+	request.DriveId = d.driveId(credential, driveId)
 	request.FileId = fileId
 
 	var resp models.VideoPreviewPlayInfoResponse
 
-	err := d.send(credential, request, &resp)
+	err := d.send(ctx, credential, request, &resp)
 
 	return &resp, err
 }
@@ -0,0 +1,366 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jakeslee/aliyundrive/http"
+	"github.com/jakeslee/aliyundrive/models"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// RapidLinkScheme 秒传链接前缀
+const RapidLinkScheme = "aliyunpan://"
+
+// RapidLink 秒传链接，只记录文件名、大小和 SHA1，不包含文件内容。
+// 文本格式为 aliyunpan://文件名|SHA1|大小|相对路径，JSON 格式直接序列化 []*RapidLink
+type RapidLink struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Sha1 string `json:"sha1"`
+	Path string `json:"path,omitempty"` // 所在目录相对导出位置的路径，以 / 分隔，顶层为空
+}
+
+func (l *RapidLink) String() string {
+	return fmt.Sprintf("%s%s|%s|%d|%s", RapidLinkScheme, l.Name, strings.ToUpper(l.Sha1), l.Size, l.Path)
+}
+
+// ParseRapidLink 解析 aliyunpan:// 格式的秒传链接，相对路径可以省略
+func ParseRapidLink(link string) (*RapidLink, error) {
+	link = strings.TrimSpace(link)
+
+	if !strings.HasPrefix(link, RapidLinkScheme) {
+		return nil, fmt.Errorf("invalid rapid link %q", link)
+	}
+
+	fields := strings.Split(strings.TrimPrefix(link, RapidLinkScheme), "|")
+
+	// 文件名和路径中可能包含 |，以 SHA1 和大小的位置确定各字段
+	for i := 1; i+1 < len(fields); i++ {
+		if !isSha1Hex(fields[i]) {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[i+1], 10, 64)
+		if err != nil || size < 0 {
+			continue
+		}
+
+		return &RapidLink{
+			Name: strings.Join(fields[:i], "|"),
+			Size: size,
+			Sha1: strings.ToUpper(fields[i]),
+			Path: strings.Trim(strings.Join(fields[i+2:], "|"), "/"),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid rapid link %q", link)
+}
+
+func isSha1Hex(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FormatRapidLinks 将秒传链接格式化为 aliyunpan:// 文本，每行一个
+func FormatRapidLinks(links []*RapidLink) string {
+	var builder strings.Builder
+
+	for _, link := range links {
+		builder.WriteString(link.String())
+		builder.WriteByte('\n')
+	}
+
+	return builder.String()
+}
+
+// ParseRapidLinks 解析秒传链接，支持 JSON 数组和每行一个的 aliyunpan:// 文本，忽略空行
+func ParseRapidLinks(data []byte) ([]*RapidLink, error) {
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("[")) {
+		var links []*RapidLink
+
+		if err := json.Unmarshal(data, &links); err != nil {
+			return nil, err
+		}
+
+		return links, nil
+	}
+
+	var links []*RapidLink
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		link, err := ParseRapidLink(line)
+		if err != nil {
+			return links, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// ExportRapidLinks 导出文件或目录的秒传链接。导出目录时递归包含所有文件，Path 以导出的目录名开头；
+// 没有 ContentHash 的文件（如未上传完成）会被跳过
+func (d *AliyunDrive) ExportRapidLinks(credential *Credential, fileId string) ([]*RapidLink, error) {
+	return d.ExportRapidLinksWithContext(context.Background(), credential, fileId)
+}
+
+// ExportRapidLinksWithContext 同 ExportRapidLinks，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ExportRapidLinksWithContext(ctx context.Context, credential *Credential, fileId string) ([]*RapidLink, error) {
+	return d.ExportRapidLinksInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// ExportRapidLinksInDrive 同 ExportRapidLinks，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) ExportRapidLinksInDrive(credential *Credential, driveId, fileId string) ([]*RapidLink, error) {
+	return d.ExportRapidLinksInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// ExportRapidLinksInDriveWithContext 同 ExportRapidLinksInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ExportRapidLinksInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) ([]*RapidLink, error) {
+	file, err := d.GetFileInDriveWithContext(ctx, credential, driveId, fileId)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []*RapidLink
+
+	err = d.exportRapidLinks(ctx, credential, driveId, file.File, "", &links)
+
+	return links, err
+}
+
+func (d *AliyunDrive) exportRapidLinks(ctx context.Context, credential *Credential, driveId string, file *models.File, dir string,
+	links *[]*RapidLink) error {
+	if file.Type != models.FileTypeFolder {
+		if file.ContentHash == "" {
+			d.logger.Warn("skip file without content hash", "file_id", file.FileId)
+			return nil
+		}
+
+		*links = append(*links, &RapidLink{
+			Name: file.Name,
+			Size: file.Size,
+			Sha1: strings.ToUpper(file.ContentHash),
+			Path: dir,
+		})
+
+		return nil
+	}
+
+	dir = path.Join(dir, file.Name)
+	marker := ""
+
+	for {
+		files, err := d.GetFolderFilesWithContext(ctx, credential, &FolderFilesOptions{
+			DriveId:      driveId,
+			FolderFileId: file.FileId,
+			Marker:       marker,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, child := range files.Items {
+			if err := d.exportRapidLinks(ctx, credential, driveId, child, dir, links); err != nil {
+				return err
+			}
+		}
+
+		if files.NextMarker == "" {
+			return nil
+		}
+
+		marker = files.NextMarker
+	}
+}
+
+type ImportRapidLinksOptions struct {
+	DriveId      string // 导入的目标 Drive，为空时使用 credential.DefaultDriveId
+	ParentFileId string // 导入的目标目录，链接的 Path 在该目录下创建，为空时导入到根目录
+
+	// ProofSource 提供文件内容用于计算 proof code，只读取其中 8 字节。
+	// 为空或返回 nil 时不携带 proof code，服务端通常会拒绝秒传
+	ProofSource func(link *RapidLink) (io.ReaderAt, error)
+}
+
+type ImportRapidLinksResult struct {
+	Files     []*models.File // 秒传成功的文件
+	Unmatched []*RapidLink   // 服务端没有匹配的文件，或 proof code 校验失败
+}
+
+// ImportRapidLinks 通过秒传在目标目录中还原秒传链接对应的文件和目录结构，同名文件自动重命名。
+// 秒传未匹配时服务端创建的待上传文件会被删除，options 为空时导入到默认 Drive 的根目录
+func (d *AliyunDrive) ImportRapidLinks(credential *Credential, links []*RapidLink, options *ImportRapidLinksOptions) (*ImportRapidLinksResult, error) {
+	return d.ImportRapidLinksWithContext(context.Background(), credential, links, options)
+}
+
+// ImportRapidLinksWithContext 同 ImportRapidLinks，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ImportRapidLinksWithContext(ctx context.Context, credential *Credential, links []*RapidLink,
+	options *ImportRapidLinksOptions) (*ImportRapidLinksResult, error) {
+	if options == nil {
+		options = &ImportRapidLinksOptions{}
+	}
+
+	parentFileId := options.ParentFileId
+	if parentFileId == "" {
+		parentFileId = DefaultRootFileId
+	}
+
+	result := &ImportRapidLinksResult{}
+
+	folders := map[string]string{"": parentFileId}
+
+	for _, link := range links {
+		linkParentFileId, err := d.ensureFolder(ctx, credential, options.DriveId, folders, strings.Trim(link.Path, "/"))
+		if err != nil {
+			return result, err
+		}
+
+		var proof io.ReaderAt
+
+		if options.ProofSource != nil {
+			proof, err = options.ProofSource(link)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		file, pending, err := d.createRapid(ctx, credential, &rapidCreateOptions{
+			driveId:      options.DriveId,
+			parentFileId: linkParentFileId,
+			name:         link.Name,
+			size:         link.Size,
+			sha1:         link.Sha1,
+			proof:        proof,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		if file != nil {
+			result.Files = append(result.Files, file)
+			continue
+		}
+
+		result.Unmatched = append(result.Unmatched, link)
+
+		// 没有文件内容无法继续上传，删除服务端创建的待上传文件
+		if pending != nil {
+			if _, err := d.DeletePermanentlyInDriveWithContext(ctx, credential, options.DriveId, pending.FileId); err != nil {
+				d.logger.Warn("delete pending upload error", "file_id", pending.FileId, "error", err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// ensureFolder 在 folders[""] 下逐级创建 dir 对应的目录，folders 缓存已创建目录的 FileId
+func (d *AliyunDrive) ensureFolder(ctx context.Context, credential *Credential, driveId string, folders map[string]string,
+	dir string) (string, error) {
+	if fileId, ok := folders[dir]; ok {
+		return fileId, nil
+	}
+
+	parent, name := path.Split(dir)
+
+	parentFileId, err := d.ensureFolder(ctx, credential, driveId, folders, strings.TrimSuffix(parent, "/"))
+	if err != nil {
+		return "", err
+	}
+
+	folder, err := d.CreateDirectoryInDriveWithContext(ctx, credential, driveId, parentFileId, name)
+	if err != nil {
+		return "", err
+	}
+
+	folders[dir] = folder.FileId
+
+	return folder.FileId, nil
+}
+
+type rapidCreateOptions struct {
+	driveId      string
+	parentFileId string
+	name         string
+	size         int64
+	sha1         string
+	proof        io.ReaderAt // 用于计算 proof code，为空时不携带
+}
+
+// createRapid 只使用 SHA1 和 proof code 秒传创建文件。服务端没有匹配时 file 为 nil，
+// 如果服务端已创建待上传的文件，pending 为创建的响应，可以继续上传分片，不再使用时需要删除
+func (d *AliyunDrive) createRapid(ctx context.Context, credential *Credential, options *rapidCreateOptions) (
+	file *models.File, pending *models.CreateWithFoldersWithProofResponse, err error) {
+	// 空文件无需秒传，直接创建
+	if options.size == 0 {
+		file, err = d.UploadFileWithContext(ctx, credential, &UploadFileOptions{
+			DriveId:      options.driveId,
+			Name:         options.name,
+			ParentFileId: options.parentFileId,
+			Reader:       bytes.NewReader(nil),
+		})
+
+		return file, nil, err
+	}
+
+	var proofCode string
+
+	if options.proof != nil {
+		proofCode, err = d.ComputeProofCodeV1(credential, options.proof, options.size)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	response, err := d.CreateWithFoldersWithContext(ctx, credential, &CreateWithFoldersOptions{
+		DriveId:      options.driveId,
+		ParentFileId: options.parentFileId,
+		Name:         options.name,
+		Size:         options.size,
+		ProofCode:    proofCode,
+		ContentHash:  strings.ToUpper(options.sha1),
+	})
+
+	var driveErr *http.AliyunDriveError
+	if errors.As(err, &driveErr) && driveErr.Code == models.CodeInvalidProofCode {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	proofResp := response.(*models.CreateWithFoldersWithProofResponse)
+	if !proofResp.RapidUpload {
+		return nil, proofResp, nil
+	}
+
+	d.EvictCacheWithPrefix(options.parentFileId)
+
+	fileResp, err := d.GetFileInDriveWithContext(ctx, credential, options.driveId, proofResp.FileId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fileResp.File, nil, nil
+}
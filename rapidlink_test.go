@@ -0,0 +1,118 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseRapidLinks(t *testing.T) {
+	links := []*RapidLink{
+		{Name: "a|b.txt", Size: 10, Sha1: strings.Repeat("AB", 20), Path: "dir|1/sub"},
+		{Name: "top.bin", Size: 0, Sha1: strings.Repeat("0F", 20)},
+	}
+
+	text := FormatRapidLinks(links)
+
+	if !strings.HasPrefix(text, "aliyunpan://a|b.txt|"+strings.Repeat("AB", 20)+"|10|dir|1/sub\n") {
+		t.Errorf("unexpected rapid link text %q", text)
+	}
+
+	data, _ := json.Marshal(links)
+
+	for _, input := range [][]byte{[]byte(text), data} {
+		parsed, err := ParseRapidLinks(input)
+		if err != nil {
+			t.Fatalf("parse rapid links error %v", err)
+		}
+
+		if len(parsed) != 2 || *parsed[0] != *links[0] || *parsed[1] != *links[1] {
+			t.Errorf("unexpected parsed links %+v, %+v", parsed[0], parsed[1])
+		}
+	}
+
+	if _, err := ParseRapidLink("aliyunpan://name|nothash|10|"); err == nil {
+		t.Errorf("invalid rapid link should fail")
+	}
+}
+
+func TestAliyunDrive_RapidLinks(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	contents := map[string][]byte{}
+
+	addFile := func(parentFileId, name string) {
+		content := []byte(strings.Repeat(name, 500))
+		server.AddFile(parentFileId, name, content)
+
+		contents[fmt.Sprintf("%X", sha1.Sum(content))] = content
+	}
+
+	folder := server.AddFolder(DefaultRootFileId, "export")
+	addFile(folder.FileId, "a.txt")
+	addFile(server.AddFolder(folder.FileId, "sub").FileId, "b.txt")
+
+	links, err := drive.ExportRapidLinks(cred, folder.FileId)
+	if err != nil {
+		t.Fatalf("export rapid links error %v", err)
+	}
+
+	if len(links) != 2 || links[0].Path != "export" || links[1].Path != "export/sub" {
+		t.Fatalf("unexpected exported links %s", FormatRapidLinks(links))
+	}
+
+	links = append(links, &RapidLink{Name: "missing.bin", Size: 100, Sha1: strings.Repeat("12", 20), Path: "export"})
+
+	target := server.AddFolder(DefaultRootFileId, "import")
+
+	result, err := drive.ImportRapidLinks(cred, links, &ImportRapidLinksOptions{
+		ParentFileId: target.FileId,
+		ProofSource: func(link *RapidLink) (io.ReaderAt, error) {
+			content, ok := contents[link.Sha1]
+			if !ok {
+				return nil, nil
+			}
+
+			return bytes.NewReader(content), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("import rapid links error %v", err)
+	}
+
+	if len(result.Files) != 2 || len(result.Unmatched) != 1 || result.Unmatched[0].Name != "missing.bin" {
+		t.Fatalf("unexpected import result %+v", result)
+	}
+
+	imported, err := drive.ExportRapidLinks(cred, target.FileId)
+	if err != nil {
+		t.Fatalf("export imported links error %v", err)
+	}
+
+	if len(imported) != 2 || imported[0].Path != "import/export" || imported[1].Path != "import/export/sub" ||
+		imported[1].Sha1 != links[1].Sha1 {
+		t.Errorf("unexpected imported tree %s", FormatRapidLinks(imported))
+	}
+
+	// 没有文件内容无法计算 proof code，秒传失败
+	result, err = drive.ImportRapidLinks(cred, links[:1], &ImportRapidLinksOptions{ParentFileId: target.FileId})
+	if err != nil || len(result.Unmatched) != 1 {
+		t.Errorf("expect unmatched without proof source, got %+v, %v", result, err)
+	}
+
+	// 服务端未匹配时创建的待上传文件需要删除，options 为空时导入到根目录
+	server.DisableRapidUpload()
+
+	result, err = drive.ImportRapidLinks(cred, links[:1], nil)
+	if err != nil || len(result.Unmatched) != 1 {
+		t.Errorf("expect unmatched with rapid upload disabled, got %+v, %v", result, err)
+	}
+
+	if pending := server.PendingUploads(); pending != 0 {
+		t.Errorf("pending uploads should be deleted, got %d", pending)
+	}
+}
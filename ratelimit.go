@@ -0,0 +1,246 @@
+package aliyundrive
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"golang.org/x/time/rate"
+	"io"
+	"time"
+)
+
+// rateScheduleLookbacks 查找限速计划上一次触发时间的回溯范围，从小到大依次查找，高频计划在较小的范围内即可找到
+var rateScheduleLookbacks = []time.Duration{time.Minute, time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}
+
+// rateScheduleMaxIterations 每个回溯范围内最多迭代的触发次数
+const rateScheduleMaxIterations = 100000
+
+// RateLimitSchedule 限速计划，Spec 触发时将上传、下载限速设置为对应的值，0 为不限速
+type RateLimitSchedule struct {
+	Spec         string // cron 表达式，如 "0 9 * * 1-5" 表示工作日 9 点
+	UploadRate   int
+	DownloadRate int
+}
+
+// newRateLimiter 创建限速为 bytesPerSec 的限速器，bytesPerSec 不大于 0 时不限速
+func newRateLimiter(bytesPerSec int) *rate.Limiter {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	setRateLimit(limiter, bytesPerSec)
+
+	return limiter
+}
+
+func setRateLimit(limiter *rate.Limiter, bytesPerSec int) {
+	if bytesPerSec <= 0 {
+		limiter.SetLimit(rate.Inf)
+		return
+	}
+
+	limiter.SetBurst(bytesPerSec)
+	limiter.SetLimit(rate.Limit(bytesPerSec))
+}
+
+func rateLimit(limiter *rate.Limiter) int {
+	if limiter.Limit() == rate.Inf {
+		return 0
+	}
+
+	return int(limiter.Limit())
+}
+
+// UploadRate 返回当前的上传限速，0 为不限速
+func (d *AliyunDrive) UploadRate() int {
+	return rateLimit(d.uploadRateLimiter)
+}
+
+// SetUploadRate 修改上传限速，对正在进行的上传立即生效，bytesPerSec 不大于 0 时不限速
+func (d *AliyunDrive) SetUploadRate(bytesPerSec int) {
+	setRateLimit(d.uploadRateLimiter, bytesPerSec)
+}
+
+// DownloadRate 返回当前所有下载共享的限速，0 为不限速
+func (d *AliyunDrive) DownloadRate() int {
+	return rateLimit(d.downloadRateLimiter)
+}
+
+// SetDownloadRate 修改所有下载共享的限速，对正在进行的下载立即生效，bytesPerSec 不大于 0 时不限速
+func (d *AliyunDrive) SetDownloadRate(bytesPerSec int) {
+	setRateLimit(d.downloadRateLimiter, bytesPerSec)
+}
+
+// SetCredentialDownloadRate 修改 credential 单独的下载限速，覆盖 Options.CredentialDownloadRate，bytesPerSec 不大于 0 时不限速
+func (d *AliyunDrive) SetCredentialDownloadRate(credential *Credential, bytesPerSec int) {
+	setRateLimit(d.credentialDownloadLimiter(credential), bytesPerSec)
+}
+
+// AddRateLimitSchedule 增加限速计划并立即应用当前所处时间段的限速，返回的 ID 用于 RemoveRateLimitSchedule
+func (d *AliyunDrive) AddRateLimitSchedule(schedule *RateLimitSchedule) (cron.EntryID, error) {
+	id, err := d.c.AddFunc(schedule.Spec, func() {
+		d.applyRateLimitSchedule(schedule)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	d.rateSchedulesMu.Lock()
+	d.rateSchedules[id] = schedule
+	d.rateSchedulesMu.Unlock()
+
+	d.c.Start()
+
+	d.logger.Info("job: rate limit scheduled", "spec", schedule.Spec,
+		"upload_rate", schedule.UploadRate, "download_rate", schedule.DownloadRate)
+
+	d.applyActiveRateLimitSchedule()
+
+	return id, nil
+}
+
+// RemoveRateLimitSchedule 删除限速计划，当前限速保持不变
+func (d *AliyunDrive) RemoveRateLimitSchedule(id cron.EntryID) {
+	d.c.Remove(id)
+
+	d.rateSchedulesMu.Lock()
+	delete(d.rateSchedules, id)
+	d.rateSchedulesMu.Unlock()
+}
+
+func (d *AliyunDrive) applyRateLimitSchedule(schedule *RateLimitSchedule) {
+	d.SetUploadRate(schedule.UploadRate)
+	d.SetDownloadRate(schedule.DownloadRate)
+
+	d.logger.Info("rate limit schedule applied", "spec", schedule.Spec)
+}
+
+// applyActiveRateLimitSchedule 应用最近一次触发的限速计划，避免等到下次触发才生效
+func (d *AliyunDrive) applyActiveRateLimitSchedule() {
+	d.rateSchedulesMu.Lock()
+
+	now := time.Now()
+
+	var active *RateLimitSchedule
+	var activeAt time.Time
+
+	for id, schedule := range d.rateSchedules {
+		if prev := prevActivation(d.c.Entry(id).Schedule, now); !prev.IsZero() && prev.After(activeAt) {
+			active, activeAt = schedule, prev
+		}
+	}
+
+	d.rateSchedulesMu.Unlock()
+
+	if active != nil {
+		d.applyRateLimitSchedule(active)
+	}
+}
+
+// prevActivation 返回 schedule 在 now 之前最近一次触发的时间，回溯范围内没有触发或触发过于频繁时返回零值
+func prevActivation(schedule cron.Schedule, now time.Time) time.Time {
+	if schedule == nil {
+		return time.Time{}
+	}
+
+	for _, lookback := range rateScheduleLookbacks {
+		var prev time.Time
+
+		t := schedule.Next(now.Add(-lookback))
+
+		// 限制迭代次数，超过时 prev 不是最近一次触发，不能使用
+		for i := 0; !t.IsZero() && !t.After(now); i++ {
+			if i >= rateScheduleMaxIterations {
+				return time.Time{}
+			}
+
+			prev = t
+			t = schedule.Next(t)
+		}
+
+		if !prev.IsZero() {
+			return prev
+		}
+	}
+
+	return time.Time{}
+}
+
+// credentialDownloadLimiter 返回 credential 单独的下载限速器，不存在时按 Options.CredentialDownloadRate 创建，
+// 按 UserId 区分，UserId 相同的 Credential 共用同一个限速器
+func (d *AliyunDrive) credentialDownloadLimiter(credential *Credential) *rate.Limiter {
+	d.downloadLimitersMu.Lock()
+	defer d.downloadLimitersMu.Unlock()
+
+	limiter, ok := d.downloadLimiters[credential.UserId]
+	if !ok {
+		limiter = newRateLimiter(d.credentialDownloadRate)
+		d.downloadLimiters[credential.UserId] = limiter
+	}
+
+	return limiter
+}
+
+// downloadRateLimiters 返回 credential 下载时需要经过的限速器
+func (d *AliyunDrive) downloadRateLimiters(credential *Credential) []*rate.Limiter {
+	limiters := []*rate.Limiter{d.downloadRateLimiter}
+
+	if credential != nil {
+		limiters = append(limiters, d.credentialDownloadLimiter(credential))
+	}
+
+	return limiters
+}
+
+type RateLimiterReader struct {
+	io.Reader
+	limiters []*rate.Limiter
+	ctx      context.Context
+}
+
+func (p *RateLimiterReader) Read(buf []byte) (n int, err error) {
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// 单次读取不超过限速器的桶容量，限速调低后在 waitN 中分批等待
+	for _, limiter := range p.limiters {
+		if burst := limiter.Burst(); limiter.Limit() != rate.Inf && burst > 0 && len(buf) > burst {
+			buf = buf[:burst]
+		}
+	}
+
+	n, err = p.Reader.Read(buf)
+
+	if n > 0 {
+		for _, limiter := range p.limiters {
+			if waitErr := waitN(ctx, limiter, n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+
+	return n, err
+}
+
+// waitN 等待 n 个令牌，n 超过桶容量时分批等待，否则 WaitN 直接返回错误
+func waitN(ctx context.Context, limiter *rate.Limiter, n int) error {
+	for n > 0 {
+		batch := n
+
+		if burst := limiter.Burst(); limiter.Limit() != rate.Inf && burst > 0 && batch > burst {
+			batch = burst
+		}
+
+		if err := limiter.WaitN(ctx, batch); err != nil {
+			return err
+		}
+
+		n -= batch
+	}
+
+	return nil
+}
+
+// rateLimitedReadCloser 限速读取下载响应，Close 时关闭原响应
+type rateLimitedReadCloser struct {
+	RateLimiterReader
+	io.Closer
+}
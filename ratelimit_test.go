@@ -0,0 +1,150 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"github.com/robfig/cron/v3"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestAliyunDrive_SetDownloadRate(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	content := bytes.Repeat([]byte("x"), 30000)
+	file := server.AddFile(DefaultRootFileId, "limited.bin", content)
+
+	response, err := drive.Download(cred, file.FileId, "")
+	if err != nil {
+		t.Fatalf("download error %v", err)
+	}
+	defer response.Body.Close()
+
+	head := make([]byte, 10000)
+	if _, err := io.ReadFull(response.Body, head); err != nil {
+		t.Fatalf("read head error %v", err)
+	}
+
+	// 下载过程中调低限速，剩余 20000 字节在桶内 10000 字节用完后约需 1s
+	drive.SetDownloadRate(10000)
+
+	if drive.DownloadRate() != 10000 {
+		t.Errorf("unexpected download rate %d", drive.DownloadRate())
+	}
+
+	start := time.Now()
+
+	if rest, err := io.ReadAll(response.Body); err != nil || len(rest) != 20000 {
+		t.Fatalf("read rest error %v, got %d bytes", err, len(rest))
+	}
+
+	if elapsed := time.Since(start); elapsed < 800*time.Millisecond {
+		t.Errorf("download should be limited after SetDownloadRate, took %s", elapsed)
+	}
+
+	drive.SetDownloadRate(0)
+	drive.SetCredentialDownloadRate(cred, 0)
+
+	if drive.DownloadRate() != 0 {
+		t.Errorf("download rate should be unlimited, got %d", drive.DownloadRate())
+	}
+}
+
+func TestAliyunDrive_CredentialDownloadRateSharedByUser(t *testing.T) {
+	drive, cred, server := newTestClientWithOptions(t, &Options{
+		CredentialDownloadRate: 20000,
+	})
+
+	content := bytes.Repeat([]byte("x"), 15000)
+	file := server.AddFile(DefaultRootFileId, "limited.bin", content)
+
+	start := time.Now()
+
+	// 每次使用新的 Credential，UserId 相同时共用限速
+	for i := 0; i < 2; i++ {
+		other := NewCredential(&Credential{
+			UserId:         cred.UserId,
+			AccessToken:    cred.AccessToken,
+			RefreshToken:   cred.RefreshToken,
+			DefaultDriveId: cred.DefaultDriveId,
+		})
+
+		response, err := drive.Download(other, file.FileId, "")
+		if err != nil {
+			t.Fatalf("download error %v", err)
+		}
+
+		data, err := io.ReadAll(response.Body)
+		_ = response.Body.Close()
+
+		if err != nil || !bytes.Equal(data, content) {
+			t.Fatalf("read limited body error %v, got %d bytes", err, len(data))
+		}
+	}
+
+	// 共用的桶内初始 20000 字节，剩余 10000 字节约需 0.5s
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("credentials of the same user should share download rate, took %s", elapsed)
+	}
+
+	if len(drive.downloadLimiters) != 1 {
+		t.Errorf("expect 1 download limiter, got %d", len(drive.downloadLimiters))
+	}
+}
+
+func TestAliyunDrive_RateLimitSchedule(t *testing.T) {
+	drive, _, _ := newTestClient(t)
+	t.Cleanup(func() { drive.c.Stop() })
+
+	// 每分钟触发的计划在添加时立即应用
+	id, err := drive.AddRateLimitSchedule(&RateLimitSchedule{
+		Spec:         "* * * * *",
+		UploadRate:   2 * 1024 * 1024,
+		DownloadRate: 4 * 1024 * 1024,
+	})
+	if err != nil {
+		t.Fatalf("add rate limit schedule error %v", err)
+	}
+
+	if drive.UploadRate() != 2*1024*1024 || drive.DownloadRate() != 4*1024*1024 {
+		t.Errorf("schedule should be applied, upload %d, download %d", drive.UploadRate(), drive.DownloadRate())
+	}
+
+	drive.RemoveRateLimitSchedule(id)
+
+	if _, err := drive.AddRateLimitSchedule(&RateLimitSchedule{Spec: "invalid"}); err == nil {
+		t.Errorf("invalid spec should fail")
+	}
+
+	schedule, _ := cron.ParseStandard("0 9 * * *")
+	now := time.Date(2022, 1, 2, 8, 0, 0, 0, time.Local)
+
+	if prev := prevActivation(schedule, now); !prev.Equal(time.Date(2022, 1, 1, 9, 0, 0, 0, time.Local)) {
+		t.Errorf("unexpected previous activation %s", prev)
+	}
+
+	every, _ := cron.ParseStandard("@every 1s")
+
+	if prev := prevActivation(every, now); now.Sub(prev) > time.Second {
+		t.Errorf("unexpected previous activation of high frequency schedule %s", prev)
+	}
+}
+
+func TestAliyunDrive_RateLimitScheduleHighFrequency(t *testing.T) {
+	drive, _, _ := newTestClient(t)
+	t.Cleanup(func() { drive.c.Stop() })
+
+	// 高频计划最近一次触发晚于每日计划，添加每日计划后仍应用高频计划
+	for _, schedule := range []*RateLimitSchedule{
+		{Spec: "@every 1s", UploadRate: 1024, DownloadRate: 2048},
+		{Spec: "0 9 * * *", UploadRate: 4096, DownloadRate: 8192},
+	} {
+		if _, err := drive.AddRateLimitSchedule(schedule); err != nil {
+			t.Fatalf("add rate limit schedule error %v", err)
+		}
+	}
+
+	if drive.UploadRate() != 1024 || drive.DownloadRate() != 2048 {
+		t.Errorf("high frequency schedule should be active, upload %d, download %d", drive.UploadRate(), drive.DownloadRate())
+	}
+}
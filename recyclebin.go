@@ -0,0 +1,153 @@
+package aliyundrive
+
+import (
+	"context"
+	"github.com/jakeslee/aliyundrive/models"
+)
+
+// ListRecycleBin 获取回收站文件列表，marker 为空时从第一页开始，下一页标记见 NextMarker
+func (d *AliyunDrive) ListRecycleBin(credential *Credential, marker string) (*models.RecycleBinListResponse, error) {
+	return d.ListRecycleBinWithContext(context.Background(), credential, marker)
+}
+
+// ListRecycleBinWithContext 同 ListRecycleBin，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ListRecycleBinWithContext(ctx context.Context, credential *Credential, marker string) (*models.RecycleBinListResponse, error) {
+	return d.ListRecycleBinInDriveWithContext(ctx, credential, "", marker)
+}
+
+// ListRecycleBinInDrive 同 ListRecycleBin，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) ListRecycleBinInDrive(credential *Credential, driveId, marker string) (*models.RecycleBinListResponse, error) {
+	return d.ListRecycleBinInDriveWithContext(context.Background(), credential, driveId, marker)
+}
+
+// ListRecycleBinInDriveWithContext 同 ListRecycleBinInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ListRecycleBinInDriveWithContext(ctx context.Context, credential *Credential, driveId, marker string) (*models.RecycleBinListResponse, error) {
+	request := models.NewRecycleBinListRequest()
+
+	request.DriveId = d.driveId(credential, driveId)
+	request.Marker = marker
+
+	var resp models.RecycleBinListResponse
+
+	err := d.send(ctx, credential, request, &resp)
+
+	return &resp, err
+}
+
+// RestoreFile 从回收站恢复文件到原目录，目录恢复时等待异步任务完成
+func (d *AliyunDrive) RestoreFile(credential *Credential, fileId string) (*models.RecycleBinResponse, error) {
+	return d.RestoreFileWithContext(context.Background(), credential, fileId)
+}
+
+// RestoreFileWithContext 同 RestoreFile，通过 ctx 控制取消和超时
+func (d *AliyunDrive) RestoreFileWithContext(ctx context.Context, credential *Credential, fileId string) (*models.RecycleBinResponse, error) {
+	return d.RestoreFileInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// RestoreFileInDrive 同 RestoreFile，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) RestoreFileInDrive(credential *Credential, driveId, fileId string) (*models.RecycleBinResponse, error) {
+	return d.RestoreFileInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// RestoreFileInDriveWithContext 同 RestoreFileInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) RestoreFileInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) (*models.RecycleBinResponse, error) {
+	request := models.NewRestoreFileRequest()
+
+	request.DriveId = d.driveId(credential, driveId)
+	request.FileId = fileId
+
+	var resp models.RecycleBinResponse
+
+	err := d.send(ctx, credential, request, &resp)
+	if err != nil {
+		return &resp, err
+	}
+
+	if resp.AsyncTaskId != "" {
+		_, err = d.WaitAsyncTaskWithContext(ctx, credential, resp.AsyncTaskId)
+	}
+
+	// 恢复后重新获取文件信息，失效原父目录的缓存
+	d.EvictCacheWithPrefix(fileId)
+	d.EvictCacheWithPrefix(d.parentFileId(ctx, credential, driveId, fileId))
+	d.evictPathCache()
+
+	return &resp, err
+}
+
+// DeletePermanently 彻底删除文件，不经过回收站且无法恢复
+func (d *AliyunDrive) DeletePermanently(credential *Credential, fileId string) (*models.RecycleBinResponse, error) {
+	return d.DeletePermanentlyWithContext(context.Background(), credential, fileId)
+}
+
+// DeletePermanentlyWithContext 同 DeletePermanently，通过 ctx 控制取消和超时
+func (d *AliyunDrive) DeletePermanentlyWithContext(ctx context.Context, credential *Credential, fileId string) (*models.RecycleBinResponse, error) {
+	return d.DeletePermanentlyInDriveWithContext(ctx, credential, "", fileId)
+}
+
+// DeletePermanentlyInDrive 同 DeletePermanently，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) DeletePermanentlyInDrive(credential *Credential, driveId, fileId string) (*models.RecycleBinResponse, error) {
+	return d.DeletePermanentlyInDriveWithContext(context.Background(), credential, driveId, fileId)
+}
+
+// DeletePermanentlyInDriveWithContext 同 DeletePermanentlyInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) DeletePermanentlyInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId string) (*models.RecycleBinResponse, error) {
+	request := models.NewDeleteFileRequest()
+
+	request.DriveId = d.driveId(credential, driveId)
+	request.FileId = fileId
+
+	var resp models.RecycleBinResponse
+
+	parentFileId := d.parentFileId(ctx, credential, driveId, fileId)
+
+	err := d.send(ctx, credential, request, &resp)
+	if err != nil {
+		return &resp, err
+	}
+
+	if resp.AsyncTaskId != "" {
+		_, err = d.WaitAsyncTaskWithContext(ctx, credential, resp.AsyncTaskId)
+	}
+
+	d.EvictCacheWithPrefix(fileId)
+	d.EvictCacheWithPrefix(parentFileId)
+	d.evictPathCache()
+
+	return &resp, err
+}
+
+// ClearRecycleBin 清空回收站，等待异步任务完成
+func (d *AliyunDrive) ClearRecycleBin(credential *Credential) (*models.RecycleBinResponse, error) {
+	return d.ClearRecycleBinWithContext(context.Background(), credential)
+}
+
+// ClearRecycleBinWithContext 同 ClearRecycleBin，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ClearRecycleBinWithContext(ctx context.Context, credential *Credential) (*models.RecycleBinResponse, error) {
+	return d.ClearRecycleBinInDriveWithContext(ctx, credential, "")
+}
+
+// ClearRecycleBinInDrive 同 ClearRecycleBin，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) ClearRecycleBinInDrive(credential *Credential, driveId string) (*models.RecycleBinResponse, error) {
+	return d.ClearRecycleBinInDriveWithContext(context.Background(), credential, driveId)
+}
+
+// ClearRecycleBinInDriveWithContext 同 ClearRecycleBinInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ClearRecycleBinInDriveWithContext(ctx context.Context, credential *Credential, driveId string) (*models.RecycleBinResponse, error) {
+	request := models.NewClearRecycleBinRequest()
+
+	request.DriveId = d.driveId(credential, driveId)
+
+	var resp models.RecycleBinResponse
+
+	err := d.send(ctx, credential, request, &resp)
+	if err != nil {
+		return &resp, err
+	}
+
+	if resp.AsyncTaskId != "" {
+		_, err = d.WaitAsyncTaskWithContext(ctx, credential, resp.AsyncTaskId)
+	}
+
+	return &resp, err
+}
@@ -0,0 +1,69 @@
+package aliyundrive
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAliyunDrive_RecycleBin(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	setInterval(t, &asyncTaskPollInterval, 10*time.Millisecond)
+
+	folder := server.AddFolder(DefaultRootFileId, "folder")
+	server.AddFile(folder.FileId, "inner.txt", []byte("inner"))
+
+	var fileIds []string
+
+	for i := 0; i < 3; i++ {
+		file := server.AddFile(DefaultRootFileId, fmt.Sprintf("file-%d.txt", i), []byte{byte(i)})
+		fileIds = append(fileIds, file.FileId)
+	}
+
+	for _, fileId := range append(fileIds, folder.FileId) {
+		if _, err := drive.RemoveFile(cred, fileId); err != nil {
+			t.Fatalf("remove %s error %v", fileId, err)
+		}
+	}
+
+	// 缓存根目录列表，恢复后应失效
+	if files, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId}); err != nil || len(files.Items) != 0 {
+		t.Fatalf("unexpected root files %+v, %v", files, err)
+	}
+
+	first, err := drive.ListRecycleBin(cred, "")
+	if err != nil || len(first.Items) != 4 || first.NextMarker != "" {
+		t.Fatalf("unexpected recycle bin %+v, %v", first, err)
+	}
+
+	if _, err := drive.RestoreFile(cred, folder.FileId); err != nil {
+		t.Fatalf("restore error %v", err)
+	}
+
+	files, err := drive.GetFolderFiles(cred, &FolderFilesOptions{FolderFileId: DefaultRootFileId})
+	if err != nil || len(files.Items) != 1 || files.Items[0].FileId != folder.FileId {
+		t.Fatalf("root folder cache should be evicted, got %+v, %v", files, err)
+	}
+
+	if children := server.Children(folder.FileId); len(children) != 1 {
+		t.Errorf("restored folder should keep children, got %+v", children)
+	}
+
+	if _, err := drive.DeletePermanently(cred, fileIds[0]); err != nil {
+		t.Fatalf("delete permanently error %v", err)
+	}
+
+	trashed, err := drive.ListRecycleBin(cred, "")
+	if err != nil || len(trashed.Items) != 2 {
+		t.Fatalf("unexpected recycle bin %+v, %v", trashed, err)
+	}
+
+	if _, err := drive.ClearRecycleBin(cred); err != nil {
+		t.Fatalf("clear recycle bin error %v", err)
+	}
+
+	if trashed, err := drive.ListRecycleBin(cred, ""); err != nil || len(trashed.Items) != 0 {
+		t.Errorf("recycle bin should be empty, got %+v, %v", trashed, err)
+	}
+}
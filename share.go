@@ -0,0 +1,103 @@
+package aliyundrive
+
+import (
+	"context"
+	"github.com/jakeslee/aliyundrive/http"
+	"github.com/jakeslee/aliyundrive/models"
+	"time"
+)
+
+type CreateShareOptions struct {
+	DriveId    string // 文件所在的 Drive，为空时使用 credential.DefaultDriveId
+	FileIds    []string
+	Name       string    // 分享名称，为空时由服务端根据文件名生成
+	Password   string    // 提取码，为空表示无需提取码
+	Expiration time.Time // 过期时间，零值表示永久有效
+}
+
+// CreateShare 分享 Drive 中的文件
+func (d *AliyunDrive) CreateShare(credential *Credential, options *CreateShareOptions) (*models.ShareLinkResponse, error) {
+	return d.CreateShareWithContext(context.Background(), credential, options)
+}
+
+// CreateShareWithContext 同 CreateShare，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CreateShareWithContext(ctx context.Context, credential *Credential, options *CreateShareOptions) (*models.ShareLinkResponse, error) {
+	request := models.NewCreateShareRequest()
+
+	request.DriveId = d.driveId(credential, options.DriveId)
+	request.FileIdList = options.FileIds
+	request.ShareName = options.Name
+	request.SharePwd = options.Password
+	request.Expiration = models.FormatShareExpiration(options.Expiration)
+
+	var resp models.ShareLinkResponse
+
+	err := d.send(ctx, credential, request, &resp)
+
+	return &resp, err
+}
+
+// ListShares 获取当前用户创建的分享列表，不包含已取消的分享
+func (d *AliyunDrive) ListShares(credential *Credential, marker string) (*models.ListSharesResponse, error) {
+	return d.ListSharesWithContext(context.Background(), credential, marker)
+}
+
+// ListSharesWithContext 同 ListShares，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ListSharesWithContext(ctx context.Context, credential *Credential, marker string) (*models.ListSharesResponse, error) {
+	request := models.NewListSharesRequest()
+
+	request.Creator = credential.UserId
+	request.Marker = marker
+
+	var resp models.ListSharesResponse
+
+	err := d.send(ctx, credential, request, &resp)
+
+	return &resp, err
+}
+
+type UpdateShareOptions struct {
+	ShareId    string
+	Name       string    // 分享名称，为空时不修改
+	Password   string    // 提取码，为空表示取消提取码
+	Expiration time.Time // 过期时间，零值表示永久有效
+}
+
+// UpdateShare 修改分享的名称、提取码和过期时间
+func (d *AliyunDrive) UpdateShare(credential *Credential, options *UpdateShareOptions) (*models.ShareLinkResponse, error) {
+	return d.UpdateShareWithContext(context.Background(), credential, options)
+}
+
+// UpdateShareWithContext 同 UpdateShare，通过 ctx 控制取消和超时
+func (d *AliyunDrive) UpdateShareWithContext(ctx context.Context, credential *Credential, options *UpdateShareOptions) (*models.ShareLinkResponse, error) {
+	request := models.NewUpdateShareRequest()
+
+	request.ShareId = options.ShareId
+	request.ShareName = options.Name
+	request.SharePwd = options.Password
+	request.Expiration = models.FormatShareExpiration(options.Expiration)
+
+	var resp models.ShareLinkResponse
+
+	err := d.send(ctx, credential, request, &resp)
+
+	return &resp, err
+}
+
+// CancelShare 取消分享
+func (d *AliyunDrive) CancelShare(credential *Credential, shareId string) (*http.BaseResponse, error) {
+	return d.CancelShareWithContext(context.Background(), credential, shareId)
+}
+
+// CancelShareWithContext 同 CancelShare，通过 ctx 控制取消和超时
+func (d *AliyunDrive) CancelShareWithContext(ctx context.Context, credential *Credential, shareId string) (*http.BaseResponse, error) {
+	request := models.NewCancelShareRequest()
+
+	request.ShareId = shareId
+
+	var resp http.BaseResponse
+
+	err := d.send(ctx, credential, request, &resp)
+
+	return &resp, err
+}
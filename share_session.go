@@ -0,0 +1,237 @@
+package aliyundrive
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jakeslee/aliyundrive/http"
+	"github.com/jakeslee/aliyundrive/models"
+	"sync"
+	"time"
+)
+
+// shareTokenRefreshAhead share token 在过期前提前刷新的时间
+const shareTokenRefreshAhead = time.Minute
+
+// ShareSession 访问他人分享的会话，自动获取和刷新 share token。
+// 浏览分享无需登录，获取下载地址和转存需要传入当前用户的 Credential
+type ShareSession struct {
+	ShareId  string
+	SharePwd string
+
+	drive      *AliyunDrive
+	mu         sync.Mutex
+	shareToken string
+	expireTime time.Time
+}
+
+// NewShareSession 创建分享会话，sharePwd 为提取码，无提取码时为空
+func (d *AliyunDrive) NewShareSession(shareId, sharePwd string) *ShareSession {
+	return &ShareSession{
+		ShareId:  shareId,
+		SharePwd: sharePwd,
+		drive:    d,
+	}
+}
+
+// GetShareInfo 匿名获取分享信息，无需提取码
+func (s *ShareSession) GetShareInfo() (*models.ShareInfoResponse, error) {
+	return s.GetShareInfoWithContext(context.Background())
+}
+
+// GetShareInfoWithContext 同 GetShareInfo，通过 ctx 控制取消和超时
+func (s *ShareSession) GetShareInfoWithContext(ctx context.Context) (*models.ShareInfoResponse, error) {
+	request := models.NewShareInfoRequest()
+
+	request.ShareId = s.ShareId
+
+	var resp models.ShareInfoResponse
+
+	err := s.drive.send(ctx, nil, request, &resp)
+
+	return &resp, err
+}
+
+// RefreshShareToken 使用提取码重新获取 share token
+func (s *ShareSession) RefreshShareToken() (*models.ShareTokenResponse, error) {
+	return s.RefreshShareTokenWithContext(context.Background())
+}
+
+// RefreshShareTokenWithContext 同 RefreshShareToken，通过 ctx 控制取消和超时
+func (s *ShareSession) RefreshShareTokenWithContext(ctx context.Context) (*models.ShareTokenResponse, error) {
+	request := models.NewShareTokenRequest()
+
+	request.ShareId = s.ShareId
+	request.SharePwd = s.SharePwd
+
+	var resp models.ShareTokenResponse
+
+	err := s.drive.send(ctx, nil, request, &resp)
+	if err != nil {
+		return &resp, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shareToken = resp.ShareToken
+	s.expireTime = resp.ExpireTime
+
+	if s.expireTime.IsZero() && resp.ExpiresIn > 0 {
+		s.expireTime = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+
+	return &resp, nil
+}
+
+// token 返回有效的 share token，未获取或即将过期时重新获取
+func (s *ShareSession) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	token, expireTime := s.shareToken, s.expireTime
+	s.mu.Unlock()
+
+	if token != "" && time.Now().Add(shareTokenRefreshAhead).Before(expireTime) {
+		return token, nil
+	}
+
+	resp, err := s.RefreshShareTokenWithContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.ShareToken, nil
+}
+
+// send 携带 share token 发送请求，share token 失效时重新获取并重试一次
+func (s *ShareSession) send(ctx context.Context, credential *Credential, r http.Request, response http.Response) error {
+	token, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	models.WithShareToken(r, token)
+
+	err = s.drive.send(ctx, credential, r, response)
+
+	value := baseResponse(response)
+	if value == nil || value.Code != models.CodeShareLinkTokenInvalid {
+		return err
+	}
+
+	resp, err := s.RefreshShareTokenWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	models.WithShareToken(r, resp.ShareToken)
+	value.Code, value.Message = "", ""
+
+	return s.drive.send(ctx, credential, r, response)
+}
+
+// ListFiles 获取分享中 parentFileId 目录下的文件列表，parentFileId 为 root 时返回分享的顶层文件
+func (s *ShareSession) ListFiles(parentFileId, marker string) (*models.ShareFilesResponse, error) {
+	return s.ListFilesWithContext(context.Background(), parentFileId, marker)
+}
+
+// ListFilesWithContext 同 ListFiles，通过 ctx 控制取消和超时
+func (s *ShareSession) ListFilesWithContext(ctx context.Context, parentFileId, marker string) (*models.ShareFilesResponse, error) {
+	request := models.NewShareFilesRequest()
+
+	request.ShareId = s.ShareId
+	request.ParentFileId = parentFileId
+	request.Marker = marker
+
+	var resp models.ShareFilesResponse
+
+	err := s.send(ctx, nil, request, &resp)
+
+	return &resp, err
+}
+
+// GetFile 获取分享中的文件信息
+func (s *ShareSession) GetFile(fileId string) (*models.FileResponse, error) {
+	return s.GetFileWithContext(context.Background(), fileId)
+}
+
+// GetFileWithContext 同 GetFile，通过 ctx 控制取消和超时
+func (s *ShareSession) GetFileWithContext(ctx context.Context, fileId string) (*models.FileResponse, error) {
+	request := models.NewShareFileRequest()
+
+	request.ShareId = s.ShareId
+	request.FileId = fileId
+
+	var resp models.FileResponse
+
+	err := s.send(ctx, nil, request, &resp)
+
+	return &resp, err
+}
+
+// GetDownloadURL 获取分享中文件的下载地址，需要登录
+func (s *ShareSession) GetDownloadURL(credential *Credential, fileId string) (*models.ShareDownloadURLResponse, error) {
+	return s.GetDownloadURLWithContext(context.Background(), credential, fileId)
+}
+
+// GetDownloadURLWithContext 同 GetDownloadURL，通过 ctx 控制取消和超时
+func (s *ShareSession) GetDownloadURLWithContext(ctx context.Context, credential *Credential, fileId string) (*models.ShareDownloadURLResponse, error) {
+	request := models.NewShareDownloadURLRequest()
+
+	request.ShareId = s.ShareId
+	request.FileId = fileId
+
+	var resp models.ShareDownloadURLResponse
+
+	err := s.send(ctx, credential, request, &resp)
+
+	return &resp, err
+}
+
+// SaveFiles 转存分享中的文件到 credential 的 toParentFileId 目录，同名文件自动重命名，结果顺序与 fileIds 一致。
+// 转存目录时服务端异步执行，可通过结果中 Copy.AsyncTaskId 等待完成
+func (s *ShareSession) SaveFiles(credential *Credential, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	return s.SaveFilesWithContext(context.Background(), credential, fileIds, toParentFileId)
+}
+
+// SaveFilesWithContext 同 SaveFiles，通过 ctx 控制取消和超时
+func (s *ShareSession) SaveFilesWithContext(ctx context.Context, credential *Credential, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	return s.SaveFilesToDriveWithContext(ctx, credential, "", fileIds, toParentFileId)
+}
+
+// SaveFilesToDrive 同 SaveFiles，转存到 toDriveId 对应的 Drive，toDriveId 为空时使用 credential.DefaultDriveId
+func (s *ShareSession) SaveFilesToDrive(credential *Credential, toDriveId string, fileIds []string, toParentFileId string) ([]*BatchResult, error) {
+	return s.SaveFilesToDriveWithContext(context.Background(), credential, toDriveId, fileIds, toParentFileId)
+}
+
+// SaveFilesToDriveWithContext 同 SaveFilesToDrive，通过 ctx 控制取消和超时
+func (s *ShareSession) SaveFilesToDriveWithContext(ctx context.Context, credential *Credential, toDriveId string, fileIds []string,
+	toParentFileId string) ([]*BatchResult, error) {
+	var requests []*models.SaveShareFileRequest
+
+	for _, fileId := range fileIds {
+		request := models.NewSaveShareFileRequest()
+
+		request.ShareId = s.ShareId
+		request.FileId = fileId
+		request.ToDriveId = s.drive.driveId(credential, toDriveId)
+		request.ToParentFileId = toParentFileId
+
+		requests = append(requests, request)
+	}
+
+	return s.drive.batch(ctx, credential, &batchOptions{
+		fileIds:      fileIds,
+		evictFolders: []string{toParentFileId},
+		newRequest: func(from, to int) *models.BatchRequest {
+			return models.NewBatchSaveShareFileRequest(requests[from:to])
+		},
+		// 每批请求单独获取 share token，转存大量文件时 share token 过期或失效会重新获取
+		send: func(ctx context.Context, r http.Request, response http.Response) error {
+			return s.send(ctx, credential, r, response)
+		},
+		parseBody: func(result *BatchResult, body []byte) error {
+			result.Copy = &models.CopyFileResponse{}
+
+			return json.Unmarshal(body, result.Copy)
+		},
+	})
+}
@@ -0,0 +1,144 @@
+package aliyundrive
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestAliyunDrive_ShareManagement(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	folder := server.AddFolder(DefaultRootFileId, "artifacts")
+	file := server.AddFile(DefaultRootFileId, "build.zip", []byte("build"))
+
+	expiration := time.Now().Add(24 * time.Hour).Truncate(time.Millisecond)
+
+	created, err := drive.CreateShare(cred, &CreateShareOptions{
+		FileIds:    []string{folder.FileId, file.FileId},
+		Password:   "abcd",
+		Expiration: expiration,
+	})
+	if err != nil {
+		t.Fatalf("create share error %v", err)
+	}
+
+	if created.ShareId == "" || created.SharePwd != "abcd" || created.ShareName != "artifacts" || len(created.FileIdList) != 2 {
+		t.Fatalf("unexpected share %+v", created)
+	}
+
+	if exp, ok := created.ExpireTime(); !ok || !exp.Equal(expiration) {
+		t.Errorf("expect expiration %s, got %s", expiration, created.Expiration)
+	}
+
+	if _, err := drive.CreateShare(cred, &CreateShareOptions{FileIds: []string{"not-exist"}}); err == nil {
+		t.Errorf("share not exist file should fail")
+	}
+
+	updated, err := drive.UpdateShare(cred, &UpdateShareOptions{
+		ShareId: created.ShareId,
+		Name:    "release",
+	})
+	if err != nil {
+		t.Fatalf("update share error %v", err)
+	}
+
+	if _, ok := updated.ExpireTime(); ok || updated.SharePwd != "" || updated.ShareName != "release" {
+		t.Errorf("unexpected updated share %+v", updated)
+	}
+
+	shares, err := drive.ListShares(cred, "")
+	if err != nil || len(shares.Items) != 1 || shares.Items[0].ShareId != created.ShareId {
+		t.Fatalf("unexpected shares %+v, %v", shares, err)
+	}
+
+	if _, err := drive.CancelShare(cred, created.ShareId); err != nil {
+		t.Fatalf("cancel share error %v", err)
+	}
+
+	if shares, err := drive.ListShares(cred, ""); err != nil || len(shares.Items) != 0 {
+		t.Errorf("cancelled share should not be listed, got %+v, %v", shares, err)
+	}
+}
+
+func TestAliyunDrive_ShareSession(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	setInterval(t, &asyncTaskPollInterval, 10*time.Millisecond)
+
+	folder := server.AddFolder(DefaultRootFileId, "public")
+	file := server.AddFile(folder.FileId, "a.txt", []byte("shared"))
+	sub := server.AddFolder(folder.FileId, "sub")
+	server.AddFile(sub.FileId, "b.txt", []byte("b"))
+
+	share, err := drive.CreateShare(cred, &CreateShareOptions{
+		FileIds:  []string{folder.FileId},
+		Password: "pwd1",
+	})
+	if err != nil {
+		t.Fatalf("create share error %v", err)
+	}
+
+	if _, err := drive.NewShareSession(share.ShareId, "wrong").ListFiles(DefaultRootFileId, ""); err == nil {
+		t.Errorf("list share with wrong password should fail")
+	}
+
+	session := drive.NewShareSession(share.ShareId, "pwd1")
+
+	info, err := session.GetShareInfo()
+	if err != nil || info.FileCount != 1 || info.FileInfos[0].FileId != folder.FileId {
+		t.Fatalf("unexpected share info %+v, %v", info, err)
+	}
+
+	top, err := session.ListFiles(DefaultRootFileId, "")
+	if err != nil || len(top.Items) != 1 || top.Items[0].FileId != folder.FileId {
+		t.Fatalf("unexpected top files %+v, %v", top, err)
+	}
+
+	// share token 失效后自动重新获取
+	server.ExpireShareTokens()
+
+	files, err := session.ListFiles(folder.FileId, "")
+	if err != nil || len(files.Items) != 2 {
+		t.Fatalf("unexpected shared folder files %+v, %v", files, err)
+	}
+
+	download, err := session.GetDownloadURL(cred, file.FileId)
+	if err != nil || download.Url == "" {
+		t.Fatalf("get share download url got %+v, %v", download, err)
+	}
+
+	response, err := server.Client().Get(download.Url)
+	if err != nil {
+		t.Fatalf("download error %v", err)
+	}
+	defer response.Body.Close()
+
+	if body, _ := io.ReadAll(response.Body); string(body) != "shared" {
+		t.Errorf("unexpected download content %s", body)
+	}
+
+	// 转存时 share token 失效，批量请求重新获取后重试
+	server.ExpireShareTokens()
+
+	results, err := session.SaveFiles(cred, []string{file.FileId, sub.FileId}, DefaultRootFileId)
+	if err != nil {
+		t.Fatalf("save files error %v", err)
+	}
+
+	for _, result := range results {
+		if result.Err() != nil || result.Copy == nil {
+			t.Fatalf("unexpected save result %+v", result)
+		}
+
+		if result.Copy.AsyncTaskId != "" {
+			if _, err := drive.WaitAsyncTask(cred, result.Copy.AsyncTaskId); err != nil {
+				t.Errorf("wait save task error %v", err)
+			}
+		}
+	}
+
+	if children := server.Children(DefaultRootFileId); len(children) != 3 {
+		t.Errorf("expect 3 files in root, got %+v", children)
+	}
+}
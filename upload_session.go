@@ -0,0 +1,363 @@
+package aliyundrive
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jakeslee/aliyundrive/models"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// UploadSession 分片上传进度，可序列化保存，用于中断后通过 ResumeUpload 继续上传
+type UploadSession struct {
+	Key          string             `json:"key"`
+	DriveId      string             `json:"drive_id"`
+	ParentFileId string             `json:"parent_file_id"`
+	Name         string             `json:"name"`
+	Size         int64              `json:"size"`
+	FileId       string             `json:"file_id"`
+	UploadId     string             `json:"upload_id"`
+	PartInfoList []*models.PartInfo `json:"part_info_list"`
+	UploadedSize int64              `json:"uploaded_size"` // 已上传字节数
+}
+
+// UploadSessionStore 上传进度存储
+type UploadSessionStore interface {
+	// Load 读取上传进度，不存在时返回 nil, nil
+	Load(key string) (*UploadSession, error)
+	Save(session *UploadSession) error
+	Delete(key string) error
+}
+
+// ErrUploadSessionNotFound 续传时找不到上传进度
+var ErrUploadSessionNotFound = errors.New("upload session not found")
+
+// MemoryUploadSessionStore 基于内存的上传进度存储，进程退出后丢失
+type MemoryUploadSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string][]byte
+}
+
+func NewMemoryUploadSessionStore() *MemoryUploadSessionStore {
+	return &MemoryUploadSessionStore{
+		sessions: make(map[string][]byte),
+	}
+}
+
+func (m *MemoryUploadSessionStore) Load(key string) (*UploadSession, error) {
+	m.mu.Lock()
+	data, ok := m.sessions[key]
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, nil
+	}
+
+	var session UploadSession
+
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (m *MemoryUploadSessionStore) Save(session *UploadSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions[session.Key] = data
+
+	return nil
+}
+
+func (m *MemoryUploadSessionStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, key)
+
+	return nil
+}
+
+// FileUploadSessionStore 将上传进度以 JSON 文件保存在 Dir 目录中
+type FileUploadSessionStore struct {
+	Dir string
+}
+
+func NewFileUploadSessionStore(dir string) *FileUploadSessionStore {
+	return &FileUploadSessionStore{
+		Dir: dir,
+	}
+}
+
+func (f *FileUploadSessionStore) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+
+	return filepath.Join(f.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (f *FileUploadSessionStore) Load(key string) (*UploadSession, error) {
+	data, err := os.ReadFile(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var session UploadSession
+
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (f *FileUploadSessionStore) Save(session *UploadSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免进程中断时留下不完整的进度
+	tmp := f.path(session.Key) + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, f.path(session.Key))
+}
+
+func (f *FileUploadSessionStore) Delete(key string) error {
+	err := os.Remove(f.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// uploadSessionKey 未指定 SessionKey 时使用 Drive、目录和文件名作为 Key
+func uploadSessionKey(driveId, parentFileId, name string) string {
+	return fmt.Sprintf("%s/%s/%s", driveId, parentFileId, name)
+}
+
+// GetUploadUrl 重新获取分片上传地址，partNumbers 为需要上传的分片序号
+func (d *AliyunDrive) GetUploadUrl(credential *Credential, fileId, uploadId string, partNumbers []int) (*models.GetUploadUrlResponse, error) {
+	return d.GetUploadUrlWithContext(context.Background(), credential, fileId, uploadId, partNumbers)
+}
+
+// GetUploadUrlWithContext 同 GetUploadUrl，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetUploadUrlWithContext(ctx context.Context, credential *Credential, fileId, uploadId string, partNumbers []int) (*models.GetUploadUrlResponse, error) {
+	return d.GetUploadUrlInDriveWithContext(ctx, credential, "", fileId, uploadId, partNumbers)
+}
+
+// GetUploadUrlInDrive 同 GetUploadUrl，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) GetUploadUrlInDrive(credential *Credential, driveId, fileId, uploadId string, partNumbers []int) (*models.GetUploadUrlResponse, error) {
+	return d.GetUploadUrlInDriveWithContext(context.Background(), credential, driveId, fileId, uploadId, partNumbers)
+}
+
+// GetUploadUrlInDriveWithContext 同 GetUploadUrlInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) GetUploadUrlInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId, uploadId string, partNumbers []int) (*models.GetUploadUrlResponse, error) {
+	request := models.NewGetUploadUrlRequest()
+
+	request.DriveId = d.driveId(credential, driveId)
+	request.FileId = fileId
+	request.UploadId = uploadId
+
+	for _, partNumber := range partNumbers {
+		request.PartInfoList = append(request.PartInfoList, &models.PartInfo{
+			PartNumber: partNumber,
+		})
+	}
+
+	var resp models.GetUploadUrlResponse
+
+	err := d.send(ctx, credential, request, &resp)
+
+	return &resp, err
+}
+
+// ListUploadedParts 获取服务端已接收的分片
+func (d *AliyunDrive) ListUploadedParts(credential *Credential, fileId, uploadId string) ([]*models.PartInfo, error) {
+	return d.ListUploadedPartsWithContext(context.Background(), credential, fileId, uploadId)
+}
+
+// ListUploadedPartsWithContext 同 ListUploadedParts，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ListUploadedPartsWithContext(ctx context.Context, credential *Credential, fileId, uploadId string) ([]*models.PartInfo, error) {
+	return d.ListUploadedPartsInDriveWithContext(ctx, credential, "", fileId, uploadId)
+}
+
+// ListUploadedPartsInDrive 同 ListUploadedParts，操作 driveId 对应的 Drive，driveId 为空时使用 credential.DefaultDriveId
+func (d *AliyunDrive) ListUploadedPartsInDrive(credential *Credential, driveId, fileId, uploadId string) ([]*models.PartInfo, error) {
+	return d.ListUploadedPartsInDriveWithContext(context.Background(), credential, driveId, fileId, uploadId)
+}
+
+// ListUploadedPartsInDriveWithContext 同 ListUploadedPartsInDrive，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ListUploadedPartsInDriveWithContext(ctx context.Context, credential *Credential, driveId, fileId, uploadId string) ([]*models.PartInfo, error) {
+	var parts []*models.PartInfo
+
+	marker := 0
+
+	for {
+		request := models.NewListUploadedPartsRequest()
+
+		request.DriveId = d.driveId(credential, driveId)
+		request.FileId = fileId
+		request.UploadId = uploadId
+		request.PartNumberMarker = marker
+
+		var resp models.ListUploadedPartsResponse
+
+		err := d.send(ctx, credential, request, &resp)
+		if err != nil {
+			return parts, err
+		}
+
+		parts = append(parts, resp.UploadedParts...)
+
+		if resp.NextPartNumberMarker == "" {
+			return parts, nil
+		}
+
+		marker, err = strconv.Atoi(resp.NextPartNumberMarker)
+		if err != nil {
+			return parts, err
+		}
+	}
+}
+
+type ResumeUploadOptions struct {
+	SessionStore     UploadSessionStore
+	SessionKey       string         // 与上传时的 UploadFileOptions.SessionKey 一致
+	Session          *UploadSession // 直接指定上传进度，为空时从 SessionStore 读取；未设置 SessionStore 时续传进度只更新到 Session 中
+	Reader           io.ReadSeeker  // 与上传时相同的文件内容
+	ProgressCallback ProgressCallback
+	ProgressDone     func(info *ProgressInfo)
+}
+
+// ResumeUpload 继续中断的分片上传。从服务端查询已上传的分片，重新获取剩余分片的上传地址，从第一个缺失的分片开始上传
+func (d *AliyunDrive) ResumeUpload(credential *Credential, options *ResumeUploadOptions) (*models.File, error) {
+	return d.ResumeUploadWithContext(context.Background(), credential, options)
+}
+
+// ResumeUploadWithContext 同 ResumeUpload，通过 ctx 控制取消和超时
+func (d *AliyunDrive) ResumeUploadWithContext(ctx context.Context, credential *Credential, options *ResumeUploadOptions) (*models.File, error) {
+	session := options.Session
+
+	if session == nil {
+		if options.SessionStore == nil {
+			return nil, ErrUploadSessionNotFound
+		}
+
+		var err error
+
+		session, err = options.SessionStore.Load(options.SessionKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if session == nil {
+			return nil, ErrUploadSessionNotFound
+		}
+	}
+
+	uploaded, err := d.ListUploadedPartsInDriveWithContext(ctx, credential, session.DriveId, session.FileId, session.UploadId)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadedParts := make(map[int]bool)
+
+	for _, part := range uploaded {
+		uploadedParts[part.PartNumber] = true
+	}
+
+	// 服务端流式计算 SHA1，分片需要按顺序上传，从第一个缺失的分片开始续传
+	first := len(session.PartInfoList)
+	session.UploadedSize = 0
+
+	for i, info := range session.PartInfoList {
+		info.IsUploaded = uploadedParts[info.PartNumber]
+
+		if !info.IsUploaded {
+			first = i
+			break
+		}
+
+		session.UploadedSize += info.EndOffset - info.StartOffset + 1
+	}
+
+	remain := session.PartInfoList[first:]
+
+	for _, info := range remain {
+		info.IsUploaded = false
+	}
+
+	if len(remain) > 0 {
+		var partNumbers []int
+
+		for _, info := range remain {
+			partNumbers = append(partNumbers, info.PartNumber)
+		}
+
+		resp, err := d.GetUploadUrlInDriveWithContext(ctx, credential, session.DriveId, session.FileId, session.UploadId, partNumbers)
+		if err != nil {
+			return nil, err
+		}
+
+		updateUploadUrls(remain, resp.PartInfoList)
+	}
+
+	return d.uploadParts(ctx, credential, &uploadPartsOptions{
+		reader:           options.Reader,
+		driveId:          session.DriveId,
+		partInfoList:     remain,
+		fileId:           session.FileId,
+		uploadId:         session.UploadId,
+		session:          session,
+		sessionStore:     options.SessionStore,
+		progressCallback: options.ProgressCallback,
+		progressDone: func(info *ProgressInfo) {
+			d.EvictCacheWithPrefix(session.ParentFileId)
+
+			if options.ProgressDone != nil {
+				options.ProgressDone(info)
+			}
+		},
+	})
+}
+
+// updateUploadUrls 按分片序号更新上传地址
+func updateUploadUrls(parts []*models.PartInfo, fresh []*models.PartInfo) {
+	urls := make(map[int]*models.PartInfo)
+
+	for _, info := range fresh {
+		urls[info.PartNumber] = info
+	}
+
+	for _, info := range parts {
+		if f, ok := urls[info.PartNumber]; ok {
+			info.UploadUrl = f.UploadUrl
+			info.InternalUploadUrl = f.InternalUploadUrl
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package aliyundrive
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingReader 读取 limit 字节后返回错误，模拟上传中断
+type failingReader struct {
+	*bytes.Reader
+	limit int64
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	offset := f.Size() - int64(f.Len())
+	if offset >= f.limit {
+		return 0, errors.New("connection reset")
+	}
+
+	if remain := f.limit - offset; int64(len(p)) > remain {
+		p = p[:remain]
+	}
+
+	return f.Reader.Read(p)
+}
+
+func TestAliyunDrive_ResumeUpload(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	setInterval(t, &partUploadRetryInterval, 10*time.Millisecond)
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), (ThunkSizeDefault*2+1024)/16)
+
+	for name, store := range map[string]UploadSessionStore{
+		"memory": NewMemoryUploadSessionStore(),
+		"file":   NewFileUploadSessionStore(t.TempDir()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			options := &UploadFileOptions{
+				Name:         name + ".bin",
+				Size:         int64(len(content)),
+				ParentFileId: DefaultRootFileId,
+				Reader: &failingReader{
+					Reader: bytes.NewReader(content),
+					limit:  ThunkSizeDefault + ThunkSizeDefault/2,
+				},
+				SessionStore: store,
+			}
+
+			if _, err := drive.UploadFile(cred, options); err == nil {
+				t.Fatalf("upload should be interrupted")
+			}
+
+			key := uploadSessionKey(cred.DefaultDriveId, DefaultRootFileId, options.Name)
+
+			session, err := store.Load(key)
+			if err != nil || session == nil {
+				t.Fatalf("upload session should be saved, got %+v, %v", session, err)
+			}
+
+			if session.UploadedSize != ThunkSizeDefault || !session.PartInfoList[0].IsUploaded || session.PartInfoList[1].IsUploaded {
+				t.Fatalf("unexpected upload session %+v", session)
+			}
+
+			var uploaded int64
+
+			file, err := drive.ResumeUpload(cred, &ResumeUploadOptions{
+				SessionStore: store,
+				SessionKey:   key,
+				Reader:       bytes.NewReader(content),
+				ProgressCallback: func(readCount int64) bool {
+					uploaded += readCount
+					return true
+				},
+			})
+			if err != nil {
+				t.Fatalf("resume upload error %v", err)
+			}
+
+			if uploaded != int64(len(content))-ThunkSizeDefault {
+				t.Errorf("only missing parts should be uploaded, got %d bytes", uploaded)
+			}
+
+			if actual, _ := server.Content(file.FileId); !bytes.Equal(actual, content) {
+				t.Errorf("unexpected content after resume, size %d", len(actual))
+			}
+
+			if session, _ := store.Load(key); session != nil {
+				t.Errorf("upload session should be deleted after complete")
+			}
+
+			if _, err := drive.ResumeUpload(cred, &ResumeUploadOptions{
+				SessionStore: store,
+				SessionKey:   key,
+				Reader:       bytes.NewReader(content),
+			}); err != ErrUploadSessionNotFound {
+				t.Errorf("expect ErrUploadSessionNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAliyunDrive_ResumeUploadWithSession(t *testing.T) {
+	drive, cred, server := newTestClient(t)
+
+	setInterval(t, &partUploadRetryInterval, 10*time.Millisecond)
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), (ThunkSizeDefault*2+1024)/16)
+	store := NewMemoryUploadSessionStore()
+
+	options := &UploadFileOptions{
+		Name:         "session.bin",
+		Size:         int64(len(content)),
+		ParentFileId: DefaultRootFileId,
+		Reader: &failingReader{
+			Reader: bytes.NewReader(content),
+			limit:  ThunkSizeDefault + ThunkSizeDefault/2,
+		},
+		SessionStore: store,
+	}
+
+	if _, err := drive.UploadFile(cred, options); err == nil {
+		t.Fatalf("upload should be interrupted")
+	}
+
+	session, err := store.Load(uploadSessionKey(cred.DefaultDriveId, DefaultRootFileId, options.Name))
+	if err != nil || session == nil {
+		t.Fatalf("upload session should be saved, got %+v, %v", session, err)
+	}
+
+	// 只传入 Session，不使用 SessionStore
+	file, err := drive.ResumeUpload(cred, &ResumeUploadOptions{
+		Session: session,
+		Reader:  bytes.NewReader(content),
+	})
+	if err != nil {
+		t.Fatalf("resume upload error %v", err)
+	}
+
+	if actual, _ := server.Content(file.FileId); !bytes.Equal(actual, content) {
+		t.Errorf("unexpected content after resume, size %d", len(actual))
+	}
+
+	if session.UploadedSize != int64(len(content)) {
+		t.Errorf("session should record uploaded size, got %d", session.UploadedSize)
+	}
+}
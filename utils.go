@@ -6,7 +6,6 @@ import (
 	"crypto/sha1"
 	"fmt"
 	"io"
-	"os"
 )
 
 func ToMD5(content string) string {
@@ -36,7 +35,15 @@ func Min(lhs, rhs int64) int64 {
 	return rhs
 }
 
-func ChecksumFileSha1(file *os.File) (string, error) {
+func Max(lhs, rhs int64) int64 {
+	if lhs >= rhs {
+		return lhs
+	}
+	return rhs
+}
+
+// ChecksumFileSha1 从头计算 file 的 SHA1
+func ChecksumFileSha1(file io.ReadSeeker) (string, error) {
 	_, err := file.Seek(0, 0)
 	if err != nil {
 		return "", err